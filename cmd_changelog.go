@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/changelog"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	input_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+	token_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/token"
+)
+
+// runChangelogCommand regenerates the changelog for a historical range of releases, e.g. v6.3.0..v6.4.0,
+// so that existing CHANGELOG.md entries can be audited or repaired.
+func runChangelogCommand(args []string) {
+	var githubToken string
+	var targetFlag string
+	var gaFlag bool
+	var betaFlag bool
+	var diffFlag bool
+	var configFlag string
+	var profileFlag string
+
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s changelog [-target=<name>|-ga|-beta] [-diff] <from>..<to>\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Regenerates the changelog between two releases or refs, e.g. v6.3.0..v6.4.0")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&githubToken, "gh_token", "", "Create a PAT with no permissions, see: https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token. Flag values are visible to other users in the process list, so prefer githubTokenFile or githubTokenCommand in config")
+	fs.StringVar(&targetFlag, "target", "", "Name of the release target in config to regenerate a changelog for")
+	fs.BoolVar(&gaFlag, "ga", false, "Flag to regenerate a changelog for the GA provider, shorthand for -target=ga")
+	fs.BoolVar(&betaFlag, "beta", false, "Flag to regenerate a changelog for the Beta provider, shorthand for -target=beta")
+	fs.BoolVar(&diffFlag, "diff", false, "Compare the regenerated changelog against the section for the <to> version in CHANGELOG.md on the trunk branch")
+	fs.StringVar(&configFlag, "config", "", "Path to the config file, overriding the default locations")
+	fs.StringVar(&profileFlag, "profile", "", "Name of a profile in the config file to use, e.g. fork-testing")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(int(failure.EXIT_USAGE))
+	}
+	from, to, err := changelog.ParseRange(fs.Arg(0))
+	if err != nil {
+		exit(&failure.InputError{Err: err})
+	}
+
+	// Stop running git commands and retries when the user presses Ctrl-C, as when preparing a release
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c, err := config.LoadConfigFromFile(configFlag, profileFlag)
+	if err != nil {
+		exit(&failure.ConfigError{Err: err})
+	}
+
+	input := input_pkg.Input{}
+	if err := input.SetTargetFromFlags(targetFlag, gaFlag, betaFlag, c.GetTargets()); err != nil {
+		exit(&failure.InputError{Err: err})
+	}
+	token, tokenSource, err := token_pkg.Resolve(githubToken, c)
+	if err != nil {
+		exit(&failure.ConfigError{Err: err})
+	}
+	log.Printf("Using GitHub token from %s", tokenSource)
+
+	templates, err := changelog.ResolveTargetTemplates(input.Target, c.MagicModulesPath)
+	if err != nil {
+		exit(&failure.ConfigError{Err: err})
+	}
+	defer templates.Cleanup()
+	for _, w := range templates.Warnings {
+		log.Printf("Warning: %s", w)
+	}
+
+	dir := input.Target.Path
+	gi := git.GitInteract{
+		Dir:         dir,
+		Remote:      input.Target.Remote,
+		TrunkBranch: input.Target.TrunkBranch,
+		Context:     ctx,
+		Timeout:     c.GetGitTimeout(),
+		Progress:    log.Writer(),
+		Retry:       retry.DEFAULT_POLICY,
+	}
+	trunk := gi.RemoteTrunkBranch()
+
+	// Mirror the release process: the range starts at the commit on the trunk branch that the <from> release was cut from,
+	// and ends at the commit on the trunk branch that the <to> release was cut from.
+	startCommit, cmd, err := gi.GetMergeBase(releaseRef(input.Target, from))
+	if err != nil {
+		exit(&failure.GitError{Summary: fmt.Sprintf("error when getting merge-base of %s and %s", trunk, from), Command: cmd})
+	}
+	endCommit, cmd, err := gi.GetMergeBase(releaseRef(input.Target, to))
+	if err != nil {
+		exit(&failure.GitError{Summary: fmt.Sprintf("error when getting merge-base of %s and %s", trunk, to), Command: cmd})
+	}
+	log.Printf("Regenerating changelog for %s between %s (%s) and %s (%s)", input.GetProviderRepoName(), from, startCommit, to, endCommit)
+
+	cl := changelog.ChangeLogRun{
+		Input:                    input,
+		LastReleaseCommit:        startCommit,
+		LastCommitCurrentRelease: endCommit,
+		Templates:                templates,
+		GitHubToken:              token,
+
+		Dir: dir,
+	}
+	if err := generateChangelog(ctx, &cl); err != nil {
+		exit(err)
+	}
+	output := cl.String()
+
+	fmt.Print("\n---\n")
+	fmt.Print("\n" + output)
+	fmt.Print("\n---\n")
+
+	if !diffFlag {
+		return
+	}
+
+	changelogFile, cmd, err := gi.ShowFile(trunk, "CHANGELOG.md")
+	if err != nil {
+		exit(&failure.GitError{Summary: fmt.Sprintf("error when reading CHANGELOG.md from the %s branch", trunk), Command: cmd})
+	}
+	existing, err := changelog.FindSection(changelogFile, to)
+	if err != nil {
+		exit(&failure.InputError{Err: err})
+	}
+	diff := changelog.Diff(existing, output)
+	if diff == "" {
+		log.Printf("The regenerated changelog matches the %s section of CHANGELOG.md", to)
+		return
+	}
+	log.Printf("The regenerated changelog differs from the %s section of CHANGELOG.md (- existing, + regenerated):", to)
+	fmt.Print("\n" + diff)
+}
+
+// releaseRef returns the tag for a version in format v1.2.3, using the target's tag template,
+// and returns any other ref unchanged
+func releaseRef(target *config.Target, ref string) string {
+	if semver.IsValid(ref) {
+		return target.TagName(ref)
+	}
+	return ref
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/build"
+	"log"
+	"os"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/setup"
+)
+
+// runConfigCommand handles commands for managing the CLI's config file
+func runConfigCommand(args []string) {
+	if len(args) == 0 {
+		printConfigUsage()
+	}
+	switch args[0] {
+	case "init":
+		runConfigInitCommand(args[1:])
+	case "schema":
+		// The JSON Schema for config files, for use with editors
+		os.Stdout.Write(config.Schema())
+	default:
+		printConfigUsage()
+	}
+}
+
+func printConfigUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s config init [-config <path>]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s config schema\n", os.Args[0])
+	os.Exit(int(failure.EXIT_USAGE))
+}
+
+// runConfigInitCommand interactively creates a config file, finding clones of the repositories and their remotes
+func runConfigInitCommand(args []string) {
+	var configFlag string
+
+	fs := flag.NewFlagSet("config init", flag.ExitOnError)
+	fs.StringVar(&configFlag, "config", "", "Path to write the config file to, overriding the default location. Files ending in .yaml, .yml or .toml are written in that format")
+	fs.Parse(args)
+
+	path := configFlag
+	if path == "" {
+		p, err := config.DefaultConfigFilePath()
+		if err != nil {
+			exit(&failure.ConfigError{Err: err})
+		}
+		path = p
+	}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+	wizard := setup.NewWizard(os.Getenv("HOME"), gopath)
+
+	if _, err := os.Stat(path); err == nil {
+		overwrite, err := wizard.Confirm(fmt.Sprintf("A config file already exists at %s. Do you want to replace it?", path))
+		if err != nil {
+			exit(&failure.InputError{Err: err})
+		}
+		if !overwrite {
+			log.Print("Leaving the existing config file unchanged")
+			return
+		}
+	}
+
+	c, err := wizard.Run()
+	if err != nil {
+		exit(&failure.InputError{Err: err})
+	}
+
+	if err := config.WriteConfigFile(path, c); err != nil {
+		exit(&failure.ConfigError{Err: err})
+	}
+
+	fmt.Println()
+	log.Printf("Config file written to %s", path)
+}
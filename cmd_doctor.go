@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/doctor"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
+	token_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/token"
+)
+
+// runDoctorCommand checks everything the release process depends on and reports all problems at once
+func runDoctorCommand(args []string) {
+	var githubToken string
+	var configFlag string
+	var profileFlag string
+	var jsonFlag bool
+
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.StringVar(&githubToken, "gh_token", "", "Create a PAT with no permissions, see: https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token. Flag values are visible to other users in the process list, so prefer githubTokenFile or githubTokenCommand in config")
+	fs.StringVar(&configFlag, "config", "", "Path to the config file, overriding the default locations")
+	fs.StringVar(&profileFlag, "profile", "", "Name of a profile in the config file to use, e.g. fork-testing")
+	fs.BoolVar(&jsonFlag, "json", false, "Flag to output the results as JSON")
+	fs.Parse(args)
+
+	// Stop the GitHub request and its retries when the user presses Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c, err := config.LoadConfigFromFile(configFlag, profileFlag)
+
+	d := doctor.Doctor{
+		Config:              c,
+		ConfigErr:           err,
+		ChangelogExecutable: changelogExecutable,
+	}
+	if c != nil {
+		token, source, err := token_pkg.Resolve(githubToken, c)
+		if err != nil {
+			d.TokenErr = err
+		} else {
+			d.GitHub = github.New(token)
+			d.TokenSource = source
+		}
+	}
+	report := d.Run(ctx)
+
+	// The report is printed rather than logged, so it doesn't go through the redacting log writer
+	if jsonFlag {
+		out, err := report.JSON()
+		if err != nil {
+			exit(err)
+		}
+		fmt.Println(secrets.Redact(out))
+	} else {
+		fmt.Print(secrets.Redact(report.Table()))
+	}
+
+	// The checks are of the config file and the environment the release runs in
+	if !report.Passed {
+		os.Exit(int(failure.EXIT_CONFIG))
+	}
+}
@@ -0,0 +1,131 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var DEFAULT_EDITOR = "vi"
+
+// SECTION_HEADERS are the headers the changelog templates group release notes under
+var SECTION_HEADERS = []string{
+	"NOTES:",
+	"DEPRECATIONS:",
+	"BREAKING CHANGES:",
+	"FEATURES:",
+	"IMPROVEMENTS:",
+	"BUG FIXES:",
+}
+
+// UNKNOWN_TYPE_HEADER is used by the changelog template for release notes with an unrecognised type
+var UNKNOWN_TYPE_HEADER = "UNKNOWN CHANGELOG TYPE:"
+
+// noteLinkRE matches the link to the PR that should end each release note, e.g. ([#123](https://github.com/hashicorp/terraform-provider-google/pull/123)),
+// optionally followed by a link to the upstream PR, e.g. ([#123](...), [GoogleCloudPlatform/magic-modules#456](https://github.com/GoogleCloudPlatform/magic-modules/pull/456))
+var noteLinkRE = regexp.MustCompile(`\(\[#(\d+)\]\(https://github\.com/[\w.-]+/[\w.-]+/pull/(\d+)\)(?:, \[[\w.-]+/[\w.-]+#(\d+)\]\(https://github\.com/[\w.-]+/[\w.-]+/pull/(\d+)\))?\)$`)
+
+type compositeValidationError []error
+
+func (v compositeValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("There were some problems with the changelog:\n")
+	for _, e := range v {
+		b.WriteString(fmt.Sprintf("\t> %v\n", e))
+	}
+	return b.String()
+}
+
+// Edit opens the changelog in the user's $EDITOR, falling back to vi, and returns the edited changelog
+func Edit(changelog string) (string, error) {
+	f, err := os.CreateTemp("", "tpg-release-cli-changelog-*.md")
+	if err != nil {
+		return "", fmt.Errorf("error creating file to edit changelog in: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(changelog); err != nil {
+		f.Close()
+		return "", fmt.Errorf("error writing changelog to %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("error closing %s: %w", f.Name(), err)
+	}
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{DEFAULT_EDITOR}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running editor `%s`: %w", cmd.String(), err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("error reading edited changelog: %w", err)
+	}
+	return string(edited), nil
+}
+
+// Validate checks that a changelog is in the format produced by the changelog templates:
+// every line is a known section header or a release note under a header, and every release note
+// ends with a link to its PR.
+func Validate(changelog string) error {
+	var errs compositeValidationError
+
+	if strings.TrimSpace(changelog) == "" {
+		return append(errs, errors.New("changelog is empty"))
+	}
+
+	header := ""
+	for n, line := range strings.Split(changelog, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		lineNumber := n + 1
+		switch {
+		case line == "":
+			continue
+		case line == UNKNOWN_TYPE_HEADER:
+			header = line
+			errs = append(errs, fmt.Errorf("line %d: release notes with unknown types need to be moved under one of the headers %s", lineNumber, strings.Join(SECTION_HEADERS, ", ")))
+		case isSectionHeader(line):
+			header = line
+		case strings.HasSuffix(line, ":") && line == strings.ToUpper(line):
+			errs = append(errs, fmt.Errorf("line %d: unknown header %q, expected one of %s", lineNumber, line, strings.Join(SECTION_HEADERS, ", ")))
+		case strings.HasPrefix(line, "* "):
+			if header == "" {
+				errs = append(errs, fmt.Errorf("line %d: release note is not under a header", lineNumber))
+			}
+			matches := noteLinkRE.FindStringSubmatch(line)
+			if matches == nil {
+				errs = append(errs, fmt.Errorf("line %d: release note should end with a link to its PR in the format ([#123](https://github.com/<owner>/<repo>/pull/123))", lineNumber))
+			} else if matches[1] != matches[2] {
+				errs = append(errs, fmt.Errorf("line %d: link text #%s doesn't match the PR number %s in the URL", lineNumber, matches[1], matches[2]))
+			} else if matches[3] != matches[4] {
+				errs = append(errs, fmt.Errorf("line %d: link text #%s doesn't match the PR number %s in the URL", lineNumber, matches[3], matches[4]))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("line %d: expected a header or a release note starting with `* `, got %q", lineNumber, line))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func isSectionHeader(line string) bool {
+	for _, h := range SECTION_HEADERS {
+		if line == h {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package changelog
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		changelog   string
+		expectError bool
+	}{
+		"valid changelog": {
+			changelog: `FEATURES:
+* **New Resource:** ` + "`google_foo`" + ` ([#100](https://github.com/hashicorp/terraform-provider-google/pull/100))
+
+BUG FIXES:
+* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))
+`,
+		},
+		"valid changelog linking to upstream PRs": {
+			changelog: `BUG FIXES:
+* compute: fixed a bug ([#12](https://github.com/GoogleCloudPlatform/magic-modules/pull/12))
+* compute: fixed another bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99), [GoogleCloudPlatform/magic-modules#13](https://github.com/GoogleCloudPlatform/magic-modules/pull/13))
+`,
+		},
+		"release note with mismatched upstream link": {
+			changelog: `BUG FIXES:
+* compute: fixed another bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99), [GoogleCloudPlatform/magic-modules#13](https://github.com/GoogleCloudPlatform/magic-modules/pull/14))
+`,
+			expectError: true,
+		},
+		"empty changelog": {
+			changelog:   "\n\n",
+			expectError: true,
+		},
+		"unknown changelog type": {
+			changelog: `UNKNOWN CHANGELOG TYPE:
+* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))
+`,
+			expectError: true,
+		},
+		"misspelled header": {
+			changelog: `BUG FIX:
+* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))
+`,
+			expectError: true,
+		},
+		"release note without a header": {
+			changelog: `* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))
+`,
+			expectError: true,
+		},
+		"release note without a link": {
+			changelog: `BUG FIXES:
+* compute: fixed a bug
+`,
+			expectError: true,
+		},
+		"release note with mismatched link": {
+			changelog: `BUG FIXES:
+* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/98))
+`,
+			expectError: true,
+		},
+		"line that isn't a header or a release note": {
+			changelog: `BUG FIXES:
+compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))
+`,
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			err := Validate(tc.changelog)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+}
+
+func TestEdit(t *testing.T) {
+	// `true` exits without changing the file, so the changelog should be returned unchanged
+	t.Setenv("EDITOR", "true")
+
+	changelog := "BUG FIXES:\n* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))\n"
+	edited, err := Edit(changelog)
+	if err != nil {
+		t.Fatalf("unexpected error(s) encountered: %v", err)
+	}
+	if edited != changelog {
+		t.Fatalf("wanted %q, got %q", changelog, edited)
+	}
+}
@@ -0,0 +1,115 @@
+package changelog
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// ParseRange splits a range in the format <from>..<to>, e.g. v6.3.0..v6.4.0, into its two refs
+func ParseRange(r string) (string, string, error) {
+	from, to, found := strings.Cut(r, "..")
+	if !found || strings.HasPrefix(to, ".") {
+		return "", "", fmt.Errorf("range %q should be in the format <from>..<to>, e.g. v6.3.0..v6.4.0", r)
+	}
+	if from == "" || to == "" {
+		return "", "", fmt.Errorf("range %q should include both a start and an end, e.g. v6.3.0..v6.4.0", r)
+	}
+	return from, to, nil
+}
+
+// FindSection returns the body of the section in a CHANGELOG.md file for the given version,
+// i.e. everything between the `## X.Y.Z (Date)` header and the next header.
+// The version can be supplied with or without a v prefix.
+func FindSection(changelogFile, version string) (string, error) {
+	version = strings.TrimPrefix(version, "v")
+	header := fmt.Sprintf("## %s ", version)
+
+	var b strings.Builder
+	inSection := false
+	scanner := bufio.NewScanner(strings.NewReader(changelogFile))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "## ") {
+			if inSection {
+				break
+			}
+			if strings.HasPrefix(line+" ", header) {
+				inSection = true
+			}
+			continue
+		}
+		if inSection {
+			b.WriteString(line + "\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if !inSection {
+		return "", fmt.Errorf("no section for version %s found in CHANGELOG.md", version)
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Diff compares two changelogs line by line and returns a diff in which removed lines are prefixed with
+// `-`, added lines are prefixed with `+` and unchanged lines are prefixed with a space.
+// Blank lines and trailing whitespace are ignored, as they vary between changelog-gen runs.
+// An empty string is returned if there are no differences.
+func Diff(old, new string) string {
+	a := diffLines(old)
+	b := diffLines(new)
+
+	// Longest common subsequence table, where lcs[i][j] is the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			changed = true
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return out.String()
+}
+
+func diffLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		l = strings.TrimRight(l, " \t\r")
+		if l == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
@@ -0,0 +1,147 @@
+package changelog
+
+import (
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := map[string]struct {
+		input        string
+		expectedFrom string
+		expectedTo   string
+		expectError  bool
+	}{
+		"versions": {
+			input:        "v6.3.0..v6.4.0",
+			expectedFrom: "v6.3.0",
+			expectedTo:   "v6.4.0",
+		},
+		"commit SHAs": {
+			input:        "33db873..a1b2c3d",
+			expectedFrom: "33db873",
+			expectedTo:   "a1b2c3d",
+		},
+		"missing end of range": {
+			input:       "v6.3.0..",
+			expectError: true,
+		},
+		"three dot range": {
+			input:       "v6.3.0...v6.4.0",
+			expectError: true,
+		},
+		"single version": {
+			input:       "v6.3.0",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			from, to, err := ParseRange(tc.input)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if from != tc.expectedFrom || to != tc.expectedTo {
+				t.Fatalf("wanted %s..%s, got %s..%s", tc.expectedFrom, tc.expectedTo, from, to)
+			}
+		})
+	}
+}
+
+func TestFindSection(t *testing.T) {
+	changelogFile := `## 6.5.0 (Unreleased)
+
+## 6.4.0 (September 23, 2024)
+
+FEATURES:
+* **New Resource:** ` + "`google_foo`" + ` ([#100](https://github.com/hashicorp/terraform-provider-google/pull/100))
+
+## 6.3.0 (September 16, 2024)
+
+BUG FIXES:
+* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))
+`
+
+	cases := map[string]struct {
+		version     string
+		expected    string
+		expectError bool
+	}{
+		"section in the middle of the file": {
+			version:  "v6.4.0",
+			expected: "FEATURES:\n* **New Resource:** `google_foo` ([#100](https://github.com/hashicorp/terraform-provider-google/pull/100))",
+		},
+		"last section without v prefix": {
+			version:  "6.3.0",
+			expected: "BUG FIXES:\n* compute: fixed a bug ([#99](https://github.com/hashicorp/terraform-provider-google/pull/99))",
+		},
+		"empty section": {
+			version:  "v6.5.0",
+			expected: "",
+		},
+		"version prefixing another version isn't matched": {
+			version:     "v6.3",
+			expectError: true,
+		},
+		"missing section": {
+			version:     "v1.0.0",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			section, err := FindSection(changelogFile, tc.version)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if section != tc.expected {
+				t.Fatalf("wanted %q, got %q", tc.expected, section)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	cases := map[string]struct {
+		old      string
+		new      string
+		expected string
+	}{
+		"identical": {
+			old:      "BUG FIXES:\n* a\n* b\n",
+			new:      "BUG FIXES:\n* a\n* b\n",
+			expected: "",
+		},
+		"blank lines and trailing whitespace are ignored": {
+			old:      "BUG FIXES:\n\n* a  \n* b\n",
+			new:      "BUG FIXES:\n* a\n* b\n\n",
+			expected: "",
+		},
+		"line changed": {
+			old:      "BUG FIXES:\n* a\n* b\n",
+			new:      "BUG FIXES:\n* a\n* c\n",
+			expected: "  BUG FIXES:\n  * a\n- * b\n+ * c\n",
+		},
+		"line added": {
+			old:      "BUG FIXES:\n* a\n",
+			new:      "BUG FIXES:\n* a\n* b\n",
+			expected: "  BUG FIXES:\n  * a\n+ * b\n",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			diff := Diff(tc.old, tc.new)
+			if diff != tc.expected {
+				t.Fatalf("wanted %q, got %q", tc.expected, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+)
+
+// upstreamPullRequestRE matches a reference to a magic-modules PR in a downstream commit message,
+// for when the upstream commit can't be used to look up its PR
+var upstreamPullRequestRE = regexp.MustCompile(`github\.com/GoogleCloudPlatform/magic-modules/pull/(\d+)`)
+
+// downstreamLinkRE matches the link to the downstream PR that changelog-gen adds at the end of each release note
+var downstreamLinkRE = regexp.MustCompile(`\(\[#(\d+)\]\((https://github\.com/[\w.-]+/[\w.-]+/pull/\d+)\)\)$`)
+
+// FindUpstreamPullRequests returns a map of downstream PR numbers to the numbers of the magic-modules PRs that they were generated from.
+//
+// The upstream PR is found using the [upstream:<sha>] trailer that the Modular Magician adds to downstream commits,
+// falling back to a link to a magic-modules PR in the downstream commit message.
+// Downstream PRs that didn't originate in magic-modules are not included. The lookups stop if ctx is cancelled.
+func FindUpstreamPullRequests(ctx context.Context, gh github.PullRequestFinder, owner, repo string, commits []git.Commit) (map[int]int, error) {
+	upstream := map[int]int{}
+	for _, c := range commits {
+		prs, err := gh.PullRequestsForCommit(ctx, owner, repo, c.Sha)
+		if err != nil {
+			return nil, err
+		}
+		downstreamPR, ok := github.MergedPullRequest(prs)
+		if !ok {
+			continue
+		}
+
+		if sha := c.UpstreamSha(); sha != "" {
+			prs, err := gh.PullRequestsForCommit(ctx, github.MAGIC_MODULES_OWNER, github.MAGIC_MODULES_REPO_NAME, sha)
+			if err != nil {
+				return nil, err
+			}
+			if upstreamPR, ok := github.MergedPullRequest(prs); ok {
+				upstream[downstreamPR.Number] = upstreamPR.Number
+				continue
+			}
+		}
+
+		if matches := upstreamPullRequestRE.FindStringSubmatch(c.Message); matches != nil {
+			n, _ := strconv.Atoi(matches[1])
+			upstream[downstreamPR.Number] = n
+		}
+	}
+	return upstream, nil
+}
+
+// RewriteLinks changes the PR link at the end of each release note according to the changelogLinks config option.
+// Release notes for downstream PRs with no known upstream PR are left unchanged.
+func RewriteLinks(changelog string, upstream map[int]int, mode string) string {
+	if mode == config.CHANGELOG_LINKS_DOWNSTREAM || mode == "" {
+		return changelog
+	}
+
+	lines := strings.Split(changelog, "\n")
+	for i, line := range lines {
+		matches := downstreamLinkRE.FindStringSubmatchIndex(line)
+		if matches == nil {
+			continue
+		}
+		downstreamNumber, _ := strconv.Atoi(line[matches[2]:matches[3]])
+		upstreamNumber, ok := upstream[downstreamNumber]
+		if !ok {
+			continue
+		}
+
+		upstreamURL := fmt.Sprintf("https://github.com/%s/%s/pull/%d", github.MAGIC_MODULES_OWNER, github.MAGIC_MODULES_REPO_NAME, upstreamNumber)
+		var link string
+		switch mode {
+		case config.CHANGELOG_LINKS_UPSTREAM:
+			link = fmt.Sprintf("([#%d](%s))", upstreamNumber, upstreamURL)
+		case config.CHANGELOG_LINKS_BOTH:
+			downstreamURL := line[matches[4]:matches[5]]
+			link = fmt.Sprintf("([#%d](%s), [%s/%s#%d](%s))", downstreamNumber, downstreamURL, github.MAGIC_MODULES_OWNER, github.MAGIC_MODULES_REPO_NAME, upstreamNumber, upstreamURL)
+		default:
+			continue
+		}
+		lines[i] = line[:matches[0]] + link
+	}
+	return strings.Join(lines, "\n")
+}
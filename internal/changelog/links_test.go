@@ -0,0 +1,84 @@
+package changelog
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github/githubtest"
+)
+
+func mergedPR(number int) []github.PullRequest {
+	return githubtest.MergedPR(github.PullRequest{Number: number})
+}
+
+func TestFindUpstreamPullRequests(t *testing.T) {
+	gh := githubtest.FakeFinder{
+		"hashicorp/terraform-provider-google/1111111": mergedPR(100),
+		"hashicorp/terraform-provider-google/2222222": mergedPR(101),
+		"hashicorp/terraform-provider-google/3333333": mergedPR(102),
+		"GoogleCloudPlatform/magic-modules/aaaaaaa":   mergedPR(5000),
+	}
+	commits := []git.Commit{
+		{Sha: "1111111", Message: "Add field\n\n[upstream:aaaaaaa]"},
+		{Sha: "2222222", Message: "Fix bug\n\nDerived from https://github.com/GoogleCloudPlatform/magic-modules/pull/5001"},
+		{Sha: "3333333", Message: "Change made directly in the downstream repo"},
+	}
+
+	got, err := FindUpstreamPullRequests(context.Background(), gh, "hashicorp", "terraform-provider-google", commits)
+	if err != nil {
+		t.Fatalf("unexpected error(s) encountered: %v", err)
+	}
+
+	want := map[int]int{100: 5000, 101: 5001}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	changelog := `BUG FIXES:
+* compute: fixed a bug ([#100](https://github.com/hashicorp/terraform-provider-google/pull/100))
+* compute: fixed a downstream-only bug ([#102](https://github.com/hashicorp/terraform-provider-google/pull/102))
+`
+	upstream := map[int]int{100: 5000}
+
+	cases := map[string]struct {
+		mode     string
+		expected string
+	}{
+		"downstream": {
+			mode:     config.CHANGELOG_LINKS_DOWNSTREAM,
+			expected: changelog,
+		},
+		"upstream": {
+			mode: config.CHANGELOG_LINKS_UPSTREAM,
+			expected: `BUG FIXES:
+* compute: fixed a bug ([#5000](https://github.com/GoogleCloudPlatform/magic-modules/pull/5000))
+* compute: fixed a downstream-only bug ([#102](https://github.com/hashicorp/terraform-provider-google/pull/102))
+`,
+		},
+		"both": {
+			mode: config.CHANGELOG_LINKS_BOTH,
+			expected: `BUG FIXES:
+* compute: fixed a bug ([#100](https://github.com/hashicorp/terraform-provider-google/pull/100), [GoogleCloudPlatform/magic-modules#5000](https://github.com/GoogleCloudPlatform/magic-modules/pull/5000))
+* compute: fixed a downstream-only bug ([#102](https://github.com/hashicorp/terraform-provider-google/pull/102))
+`,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			got := RewriteLinks(changelog, upstream, tc.mode)
+			if got != tc.expected {
+				t.Fatalf("wanted %q, got %q", tc.expected, got)
+			}
+			if err := Validate(got); err != nil {
+				t.Fatalf("rewritten changelog should be valid: %v", err)
+			}
+		})
+	}
+}
@@ -2,18 +2,20 @@ package changelog
 
 import (
 	"bytes"
-	"fmt"
+	"os"
 	"os/exec"
 
-	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
 	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
 )
 
 type ChangeLogRun struct {
 	Input                    input.Input
-	Config                   *config.Config
 	LastReleaseCommit        string
 	LastCommitCurrentRelease string
+	Templates                *Templates
+
+	// GitHubToken is passed to changelog-gen through its environment, rather than being set for the whole process
+	GitHubToken string
 
 	Dir    string
 	StdErr *bytes.Buffer
@@ -24,15 +26,16 @@ func (cl *ChangeLogRun) GenerateChangelog() error {
 
 	changelogCmd := exec.Command("changelog-gen",
 		"-repo", cl.Input.GetProviderRepoName(),
-		"-branch", "main",
-		"-owner", cl.Config.RemoteOwner,
-		"-changelog", fmt.Sprintf("%s/.ci/changelog.tmpl", cl.Config.MagicModulesPath),
-		"-releasenote", fmt.Sprintf("%s/.ci/release-note.tmpl", cl.Config.MagicModulesPath),
+		"-branch", cl.Input.Target.TrunkBranch,
+		"-owner", cl.Input.Target.Owner,
+		"-changelog", cl.Templates.ChangelogPath,
+		"-releasenote", cl.Templates.ReleaseNotePath,
 		"-no-note-label", "\"changelog: no-release-note\"",
 		cl.LastReleaseCommit,
 		cl.LastCommitCurrentRelease,
 	)
 	changelogCmd.Dir = cl.Dir
+	changelogCmd.Env = append(os.Environ(), "GITHUB_TOKEN="+cl.GitHubToken)
 
 	cl.StdErr = &bytes.Buffer{}
 	changelogCmd.Stderr = cl.StdErr
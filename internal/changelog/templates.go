@@ -0,0 +1,177 @@
+package changelog
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+)
+
+// Default copies of the templates kept in magic-modules' .ci/ directory. These are used
+// when the user doesn't have a magic-modules clone, or the clone is missing the templates.
+//
+//go:embed templates/changelog.tmpl templates/release-note.tmpl
+var embeddedTemplates embed.FS
+
+var CHANGELOG_TEMPLATE_NAME = "changelog.tmpl"
+var RELEASE_NOTE_TEMPLATE_NAME = "release-note.tmpl"
+
+// Templates describes where the templates passed to changelog-gen are located
+type Templates struct {
+	ChangelogPath   string
+	ReleaseNotePath string
+
+	// Embedded records whether the paths point at copies of the embedded templates
+	Embedded bool
+	// Warnings contains problems found with the templates that aren't severe enough to stop a release
+	Warnings []string
+
+	tmpDir string
+}
+
+// templateFuncs contains stubs of the functions that changelog-gen makes available to templates,
+// so templates can be parsed for validation without failing on unknown functions.
+var templateFuncs = template.FuncMap{
+	"combineTypes": func(...interface{}) interface{} { return nil },
+	"sort":         func(interface{}) interface{} { return nil },
+}
+
+// ResolveTemplates finds the changelog templates that should be used by changelog-gen.
+//
+// If magicModulesPath is set the templates in its .ci/ directory are validated and used,
+// with a warning recorded if they differ from the embedded copies. If magicModulesPath is empty,
+// or the templates are missing from it, the embedded templates are written to a temporary
+// directory and used instead.
+// Callers should call Cleanup once the templates are no longer needed.
+func ResolveTemplates(magicModulesPath string) (*Templates, error) {
+	if magicModulesPath == "" {
+		t, err := writeEmbeddedTemplates()
+		if err != nil {
+			return nil, err
+		}
+		t.Warnings = append(t.Warnings, "magicModulesPath is not set in config, using the changelog templates embedded in the CLI")
+		return t, nil
+	}
+
+	t := &Templates{
+		ChangelogPath:   filepath.Join(magicModulesPath, ".ci", CHANGELOG_TEMPLATE_NAME),
+		ReleaseNotePath: filepath.Join(magicModulesPath, ".ci", RELEASE_NOTE_TEMPLATE_NAME),
+	}
+
+	changelogTmpl, err := os.ReadFile(t.ChangelogPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading changelog template: %w", err)
+	}
+	releaseNoteTmpl, err2 := os.ReadFile(t.ReleaseNotePath)
+	if err2 != nil && !errors.Is(err2, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading release note template: %w", err2)
+	}
+	if err != nil || err2 != nil {
+		// At least one template is missing from the clone
+		t, err := writeEmbeddedTemplates()
+		if err != nil {
+			return nil, err
+		}
+		t.Warnings = append(t.Warnings, fmt.Sprintf("changelog templates not found in %s, using the templates embedded in the CLI", filepath.Join(magicModulesPath, ".ci")))
+		return t, nil
+	}
+
+	if err := validateTemplates(changelogTmpl, releaseNoteTmpl); err != nil {
+		return nil, fmt.Errorf("error validating templates in %s: %w", filepath.Join(magicModulesPath, ".ci"), err)
+	}
+
+	for path, contents := range map[string][]byte{
+		t.ChangelogPath:   changelogTmpl,
+		t.ReleaseNotePath: releaseNoteTmpl,
+	} {
+		embedded, err := embeddedTemplates.ReadFile("templates/" + filepath.Base(path))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(bytes.TrimSpace(contents), bytes.TrimSpace(embedded)) {
+			t.Warnings = append(t.Warnings, fmt.Sprintf("template %s differs from the copy embedded in the CLI, it may have been updated in magic-modules", path))
+		}
+	}
+
+	return t, nil
+}
+
+// ResolveTargetTemplates finds the changelog templates for a release target. Targets can set their own templates,
+// e.g. for repositories that don't share magic-modules' changelog format, otherwise ResolveTemplates is used.
+func ResolveTargetTemplates(target *config.Target, magicModulesPath string) (*Templates, error) {
+	if target.ChangelogTemplate == "" && target.ReleaseNoteTemplate == "" {
+		return ResolveTemplates(magicModulesPath)
+	}
+
+	t := &Templates{
+		ChangelogPath:   target.ChangelogTemplate,
+		ReleaseNotePath: target.ReleaseNoteTemplate,
+	}
+	changelogTmpl, err := os.ReadFile(t.ChangelogPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading changelog template of target %q: %w", target.Name, err)
+	}
+	releaseNoteTmpl, err := os.ReadFile(t.ReleaseNotePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading release note template of target %q: %w", target.Name, err)
+	}
+	if err := validateTemplates(changelogTmpl, releaseNoteTmpl); err != nil {
+		return nil, fmt.Errorf("error validating templates of target %q: %w", target.Name, err)
+	}
+	return t, nil
+}
+
+// Cleanup removes any temporary files created by ResolveTemplates
+func (t *Templates) Cleanup() error {
+	if t.tmpDir == "" {
+		return nil
+	}
+	return os.RemoveAll(t.tmpDir)
+}
+
+func writeEmbeddedTemplates() (*Templates, error) {
+	dir, err := os.MkdirTemp("", "tpg-release-cli-templates")
+	if err != nil {
+		return nil, fmt.Errorf("error creating directory for embedded changelog templates: %w", err)
+	}
+
+	t := &Templates{
+		ChangelogPath:   filepath.Join(dir, CHANGELOG_TEMPLATE_NAME),
+		ReleaseNotePath: filepath.Join(dir, RELEASE_NOTE_TEMPLATE_NAME),
+		Embedded:        true,
+		tmpDir:          dir,
+	}
+
+	for _, path := range []string{t.ChangelogPath, t.ReleaseNotePath} {
+		contents, err := embeddedTemplates.ReadFile("templates/" + filepath.Base(path))
+		if err != nil {
+			return nil, errors.Join(err, t.Cleanup())
+		}
+		if err := os.WriteFile(path, contents, 0o644); err != nil {
+			return nil, errors.Join(fmt.Errorf("error writing embedded changelog template: %w", err), t.Cleanup())
+		}
+	}
+
+	return t, nil
+}
+
+// validateTemplates asserts the templates parse in the same way changelog-gen combines them
+func validateTemplates(changelogTmpl, releaseNoteTmpl []byte) error {
+	tmpl, err := template.New("release-note").Funcs(templateFuncs).Parse(string(releaseNoteTmpl))
+	if err != nil {
+		return fmt.Errorf("%s does not parse: %w", RELEASE_NOTE_TEMPLATE_NAME, err)
+	}
+	if tmpl.Lookup("note") == nil {
+		return fmt.Errorf("%s does not define a \"note\" template", RELEASE_NOTE_TEMPLATE_NAME)
+	}
+	if _, err := tmpl.New("changelog").Parse(string(changelogTmpl)); err != nil {
+		return fmt.Errorf("%s does not parse: %w", CHANGELOG_TEMPLATE_NAME, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveTemplates(t *testing.T) {
+
+	changelogTmpl, err := embeddedTemplates.ReadFile("templates/" + CHANGELOG_TEMPLATE_NAME)
+	if err != nil {
+		t.Fatalf("error reading embedded template: %s", err)
+	}
+	releaseNoteTmpl, err := embeddedTemplates.ReadFile("templates/" + RELEASE_NOTE_TEMPLATE_NAME)
+	if err != nil {
+		t.Fatalf("error reading embedded template: %s", err)
+	}
+
+	cases := map[string]struct {
+		changelogTmpl    string
+		releaseNoteTmpl  string
+		noMagicModules   bool
+		expectEmbedded   bool
+		expectedWarnings int
+		expectError      bool
+	}{
+		"magicModulesPath unset uses embedded templates": {
+			noMagicModules:   true,
+			expectEmbedded:   true,
+			expectedWarnings: 1,
+		},
+		"templates missing from magic-modules uses embedded templates": {
+			expectEmbedded:   true,
+			expectedWarnings: 1,
+		},
+		"templates matching embedded copies": {
+			changelogTmpl:   string(changelogTmpl),
+			releaseNoteTmpl: string(releaseNoteTmpl),
+		},
+		"templates differing from embedded copies": {
+			changelogTmpl:    string(changelogTmpl) + "\nFOOBAR\n",
+			releaseNoteTmpl:  string(releaseNoteTmpl),
+			expectedWarnings: 1,
+		},
+		"changelog template that doesn't parse": {
+			changelogTmpl:   "{{ if .NotesByType.bug }}",
+			releaseNoteTmpl: string(releaseNoteTmpl),
+			expectError:     true,
+		},
+		"release note template without a note definition": {
+			changelogTmpl:   string(changelogTmpl),
+			releaseNoteTmpl: "{{ .Body }}",
+			expectError:     true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			mmPath := t.TempDir()
+			if tc.changelogTmpl != "" || tc.releaseNoteTmpl != "" {
+				ciDir := filepath.Join(mmPath, ".ci")
+				if err := os.Mkdir(ciDir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(ciDir, CHANGELOG_TEMPLATE_NAME), []byte(tc.changelogTmpl), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(ciDir, RELEASE_NOTE_TEMPLATE_NAME), []byte(tc.releaseNoteTmpl), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.noMagicModules {
+				mmPath = ""
+			}
+
+			templates, err := ResolveTemplates(mmPath)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if err != nil {
+				return
+			}
+			defer templates.Cleanup()
+
+			if templates.Embedded != tc.expectEmbedded {
+				t.Fatalf("wanted Embedded to be %v, got %v", tc.expectEmbedded, templates.Embedded)
+			}
+			if len(templates.Warnings) != tc.expectedWarnings {
+				t.Fatalf("wanted %d warnings, got %d: %v", tc.expectedWarnings, len(templates.Warnings), templates.Warnings)
+			}
+			for _, path := range []string{templates.ChangelogPath, templates.ReleaseNotePath} {
+				if _, err := os.Stat(path); err != nil {
+					t.Fatalf("expected template to exist at %s: %s", path, err)
+				}
+			}
+		})
+	}
+}
@@ -1,18 +1,27 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	MagicModulesPath string `json:"magicModulesPath"`
-	GooglePath       string `json:"googlePath"`
-	GoogleBetaPath   string `json:"googleBetaPath"`
-	Remote           string `json:"remote"`
+	MagicModulesPath string `json:"magicModulesPath,omitempty"`
+
+	// Targets are the repositories that releases can be made for. If unset, googlePath and googleBetaPath
+	// are used to create targets called ga and beta.
+	Targets        []Target `json:"targets,omitempty"`
+	GooglePath     string   `json:"googlePath,omitempty"`
+	GoogleBetaPath string   `json:"googleBetaPath,omitempty"`
+
+	// Remote is the default remote for all targets
+	Remote string `json:"remote"`
 
 	// RemoteOwner defaults to 'hashicorp' but can be set in config to enable using
 	// the CLI with a fork of the official HashiCorp repository.
@@ -21,7 +30,46 @@ type Config struct {
 	// GitHub token is a personal access token with no permissions
 	// It is used by changelog-gen
 	// https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token
-	GitHubToken string `json:githubToken`
+	GitHubToken string `json:"githubToken,omitempty"`
+
+	// GitHubTokenFile and GitHubTokenCommand are alternatives to storing the token in the config file:
+	// a path to a file containing the token that only the user can access, or a credential helper
+	// command that prints the token, e.g. a password manager CLI
+	GitHubTokenFile    string `json:"githubTokenFile,omitempty"`
+	GitHubTokenCommand string `json:"githubTokenCommand,omitempty"`
+
+	// Maintainers are GitHub usernames that are excluded when crediting contributors to a release
+	Maintainers []string `json:"maintainers,omitempty"`
+
+	// ChangelogLinks controls which PR each changelog entry links to: the downstream provider PR (default),
+	// the upstream magic-modules PR the change originated from, or both
+	ChangelogLinks string `json:"changelogLinks"`
+
+	// CommitCutoff is the weekly deadline for commits to be included in a release, e.g. "Monday 17:00".
+	// The commit picker suggests the last commit before the most recent cutoff.
+	CommitCutoff string `json:"commitCutoff,omitempty"`
+
+	// GitTimeout is how long each git command can run for before it's stopped, e.g. "5m". Defaults to
+	// DEFAULT_GIT_TIMEOUT, and "0" means no limit.
+	GitTimeout Duration `json:"gitTimeout,omitempty"`
+}
+
+// Duration is a duration in config, e.g. "5m". A number is accepted as a number of seconds, so that `gitTimeout: 0`
+// in a YAML or TOML file, which is decoded as an integer rather than a string, isn't a type error.
+type Duration string
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = Duration(s)
+		return nil
+	}
+	var seconds int64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("should be a duration like \"5m\" or a whole number of seconds, got %s", data)
+	}
+	*d = Duration(fmt.Sprintf("%ds", seconds))
+	return nil
 }
 
 type compositeValidationError []error
@@ -39,46 +87,62 @@ func (ve compositeValidationError) Error() string {
 }
 
 var CONFIG_FILE_NAME = ".tpg-cli-config.json"
+var XDG_CONFIG_DIR_NAME = "tpg-release"
+var XDG_CONFIG_FILE_NAME = "config.json"
+
+// XDG_CONFIG_FILE_NAMES are the names of config files searched for in the XDG config directory, in order
+var XDG_CONFIG_FILE_NAMES = []string{XDG_CONFIG_FILE_NAME, "config.yaml", "config.yml", "config.toml"}
+
+// ENV_PREFIX is the prefix of environment variables that override config values, e.g. TPG_CLI_REMOTE
+var ENV_PREFIX = "TPG_CLI_"
+
 var GA_REPO_NAME = "terraform-provider-google"
 var BETA_REPO_NAME = "terraform-provider-google-beta"
 
+var CHANGELOG_LINKS_DOWNSTREAM = "downstream"
+var CHANGELOG_LINKS_UPSTREAM = "upstream"
+var CHANGELOG_LINKS_BOTH = "both"
+
+// DEFAULT_GIT_TIMEOUT is how long each git command can run for when gitTimeout isn't set. Pulling tags from the large
+// provider repositories can take a few minutes.
+var DEFAULT_GIT_TIMEOUT = 15 * time.Minute
+
 func (c *Config) validate() error {
 
 	var errs compositeValidationError
 
-	if c.MagicModulesPath == "" {
-		errs = append(errs, errors.New("error in loaded config: magicModulesPath is empty/missing"))
-	} else {
+	// magicModulesPath is optional; the CLI has embedded copies of the changelog templates
+	if c.MagicModulesPath != "" {
 		_, err := os.ReadDir(c.MagicModulesPath)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("error opening magicModulesPath path: %w", err))
 		}
 	}
 
-	if c.GooglePath == "" {
-		errs = append(errs, errors.New("error in loaded config: googlePath is empty/missing"))
-	} else {
-		_, err := os.ReadDir(c.GooglePath)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("error opening googlePath path: %w", err))
-		}
+	errs = append(errs, c.validateTargets()...)
+
+	if c.RemoteOwner == "" {
+		errs = append(errs, errors.New("error in loaded config: remote repo owner is empty/missing"))
 	}
 
-	if c.GoogleBetaPath == "" {
-		errs = append(errs, errors.New("error in loaded config: googleBetaPath is empty/missing"))
-	} else {
-		_, err := os.ReadDir(c.GoogleBetaPath)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("error opening googleBetaPath path: %w", err))
-		}
+	switch c.ChangelogLinks {
+	case "", CHANGELOG_LINKS_DOWNSTREAM, CHANGELOG_LINKS_UPSTREAM, CHANGELOG_LINKS_BOTH:
+	default:
+		errs = append(errs, fmt.Errorf("error in loaded config: changelogLinks should be one of %q, %q or %q, got %q", CHANGELOG_LINKS_DOWNSTREAM, CHANGELOG_LINKS_UPSTREAM, CHANGELOG_LINKS_BOTH, c.ChangelogLinks))
 	}
 
-	if c.Remote == "" {
-		errs = append(errs, errors.New("error in loaded config: remote is empty/missing"))
+	if c.CommitCutoff != "" {
+		if _, err := ParseCutoff(c.CommitCutoff); err != nil {
+			errs = append(errs, fmt.Errorf("error in loaded config: commitCutoff: %w", err))
+		}
 	}
 
-	if c.RemoteOwner == "" {
-		errs = append(errs, errors.New("error in loaded config: remote repo owner is empty/missing"))
+	if c.GitTimeout != "" {
+		if d, err := time.ParseDuration(string(c.GitTimeout)); err != nil {
+			errs = append(errs, fmt.Errorf("error in loaded config: gitTimeout should be a duration like \"5m\": %w", err))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("error in loaded config: gitTimeout can't be negative, got %q", c.GitTimeout))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -88,48 +152,181 @@ func (c *Config) validate() error {
 	return nil
 }
 
-func LoadConfigFromFile() (*Config, error) {
+// GetGitTimeout returns how long each git command can run for, or zero for no limit
+func (c *Config) GetGitTimeout() time.Duration {
+	if c.GitTimeout == "" {
+		return DEFAULT_GIT_TIMEOUT
+	}
+	// The value is checked when the config is loaded
+	d, _ := time.ParseDuration(string(c.GitTimeout))
+	return d
+}
+
+// LoadConfigFromFile loads config from a file, applies the named profile and any TPG_CLI_* environment
+// variable overrides, and validates the result.
+//
+// The file is found using the first of:
+//   - the path argument, e.g. from a -config flag
+//   - the TPG_CLI_CONFIG environment variable
+//   - $XDG_CONFIG_HOME/tpg-release/config.{json,yaml,yml,toml} (XDG_CONFIG_HOME defaults to ~/.config), if it exists
+//   - ~/.tpg-cli-config.json
+//
+// Files can be JSON, YAML or TOML, based on their extension. Keys that don't correspond to a config value
+// are reported as errors.
+//
+// If profile is empty the TPG_CLI_PROFILE environment variable is used, if set.
+func LoadConfigFromFile(path, profile string) (*Config, error) {
 
 	var errs compositeValidationError
-	home := os.Getenv("HOME")
-	if home == "" {
-		return nil, append(errs, errors.New("cannot find HOME environment variable, please make sure it is available"))
+
+	path, err := findConfigFile(path)
+	if err != nil {
+		return nil, append(errs, err)
 	}
 
-	path := fmt.Sprintf("%s/%s", home, CONFIG_FILE_NAME)
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, append(errs, fmt.Errorf("error opening ~/%s: %w", CONFIG_FILE_NAME, err))
+		return nil, append(errs, fmt.Errorf("error opening config file %s: %w", path, err))
+	}
+
+	data, err = toJSON(data, formatFromPath(path))
+	if err != nil {
+		return nil, append(errs, fmt.Errorf("error parsing config file %s: %w", path, err))
+	}
+
+	cf := configFile{}
+	if err = decodeStrict(data, &cf); err != nil {
+		return nil, append(errs, fmt.Errorf("error parsing config file %s: %w", path, err))
 	}
+	config := cf.Config
 
-	jsonParser := json.NewDecoder(f)
-	config := Config{}
-	if err = jsonParser.Decode(&config); err != nil {
-		return nil, append(errs, fmt.Errorf("error parsing config file: %w", err))
+	if profile == "" {
+		profile = os.Getenv(ENV_PREFIX + "PROFILE")
+	}
+	if profile != "" {
+		p, ok := cf.Profiles[profile]
+		if !ok {
+			return nil, append(errs, fmt.Errorf("profile %q not found in config file %s", profile, path))
+		}
+		// Only fields present in the profile replace the top-level values
+		if err = decodeStrict(p, &config); err != nil {
+			return nil, append(errs, fmt.Errorf("error parsing profile %q in config file %s: %w", profile, path, err))
+		}
 	}
+
+	config.applyEnvOverrides()
+
 	if config.RemoteOwner == "" {
 		config.RemoteOwner = "hashicorp"
 	}
+	if config.ChangelogLinks == "" {
+		config.ChangelogLinks = CHANGELOG_LINKS_DOWNSTREAM
+	}
 
 	err = config.validate()
 	if err != nil {
 		return nil, append(errs, err)
 	}
 
-	if len(errs) > 0 {
-		return nil, append(errs, err)
+	return &config, nil
+}
+
+// configFile describes the contents of a config file: top-level config values, and named profiles
+// that override some of those values
+type configFile struct {
+	// Schema allows editors to find the JSON Schema for the file, see `config schema`
+	Schema string `json:"$schema,omitempty"`
+	Config
+	Profiles map[string]json.RawMessage `json:"profiles"`
+}
+
+// DefaultConfigFilePath returns the path of the config file that is loaded when no path is supplied
+func DefaultConfigFilePath() (string, error) {
+	return findConfigFile("")
+}
+
+// WriteConfigFile validates the config and writes it to a new file at the given path, in the format
+// matching the file's extension
+func WriteConfigFile(path string, c *Config) error {
+	if err := c.validate(); err != nil {
+		return err
 	}
 
-	return &config, nil
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	data, err = fromJSON(data, formatFromPath(path))
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating directory for config file %s: %w", path, err)
+	}
+	// The file can contain a githubToken, or githubTokenFile or githubTokenCommand settings that say where to find one,
+	// so it should only be readable by the user
+	if err := os.WriteFile(path, append(bytes.TrimRight(data, "\n"), '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing config file %s: %w", path, err)
+	}
+	return nil
 }
 
-func (c *Config) GetProviderDirectoryPath(provider string) string {
-	switch provider {
-	case GA_REPO_NAME:
-		return c.GoogleBetaPath
-	case BETA_REPO_NAME:
-		return c.GooglePath
-	default:
-		return fmt.Sprintf("no directory in config for provider %s", provider)
+func findConfigFile(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if p := os.Getenv(ENV_PREFIX + "CONFIG"); p != "" {
+		return p, nil
+	}
+
+	home := os.Getenv("HOME")
+
+	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfigHome == "" && home != "" {
+		xdgConfigHome = filepath.Join(home, ".config")
+	}
+	if xdgConfigHome != "" {
+		for _, name := range XDG_CONFIG_FILE_NAMES {
+			p := filepath.Join(xdgConfigHome, XDG_CONFIG_DIR_NAME, name)
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+	}
+
+	if home == "" {
+		return "", errors.New("cannot find HOME environment variable, please make sure it is available")
+	}
+	return filepath.Join(home, CONFIG_FILE_NAME), nil
+}
+
+// applyEnvOverrides replaces config values with those set in TPG_CLI_* environment variables
+func (c *Config) applyEnvOverrides() {
+	for name, field := range map[string]*string{
+		"MAGIC_MODULES_PATH":   &c.MagicModulesPath,
+		"GOOGLE_PATH":          &c.GooglePath,
+		"GOOGLE_BETA_PATH":     &c.GoogleBetaPath,
+		"REMOTE":               &c.Remote,
+		"REMOTE_OWNER":         &c.RemoteOwner,
+		"GITHUB_TOKEN":         &c.GitHubToken,
+		"GITHUB_TOKEN_FILE":    &c.GitHubTokenFile,
+		"GITHUB_TOKEN_COMMAND": &c.GitHubTokenCommand,
+		"CHANGELOG_LINKS":      &c.ChangelogLinks,
+		"COMMIT_CUTOFF":        &c.CommitCutoff,
+		"GIT_TIMEOUT":          (*string)(&c.GitTimeout),
+	} {
+		if v, ok := os.LookupEnv(ENV_PREFIX + name); ok {
+			*field = v
+		}
+	}
+
+	// Lists are supplied as comma-separated values
+	if v, ok := os.LookupEnv(ENV_PREFIX + "MAINTAINERS"); ok {
+		c.Maintainers = nil
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				c.Maintainers = append(c.Maintainers, m)
+			}
+		}
 	}
 }
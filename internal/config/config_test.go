@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -25,7 +27,6 @@ func TestConfig_validate(t *testing.T) {
 			},
 		},
 		"MagicModulesPath unset": {
-			expectError: true,
 			config: &Config{
 				GooglePath:     tmpDir,
 				GoogleBetaPath: tmpDir,
@@ -75,6 +76,54 @@ func TestConfig_validate(t *testing.T) {
 				Remote:           tmpDir,
 			},
 		},
+		"ChangelogLinks set": {
+			config: &Config{
+				MagicModulesPath: tmpDir,
+				GooglePath:       tmpDir,
+				GoogleBetaPath:   tmpDir,
+				Remote:           tmpDir,
+				ChangelogLinks:   CHANGELOG_LINKS_BOTH,
+			},
+		},
+		"ChangelogLinks bad value": {
+			expectError: true,
+			config: &Config{
+				MagicModulesPath: tmpDir,
+				GooglePath:       tmpDir,
+				GoogleBetaPath:   tmpDir,
+				Remote:           tmpDir,
+				ChangelogLinks:   "sideways",
+			},
+		},
+		"GitTimeout set": {
+			config: &Config{
+				MagicModulesPath: tmpDir,
+				GooglePath:       tmpDir,
+				GoogleBetaPath:   tmpDir,
+				Remote:           tmpDir,
+				GitTimeout:       "5m",
+			},
+		},
+		"GitTimeout bad value": {
+			expectError: true,
+			config: &Config{
+				MagicModulesPath: tmpDir,
+				GooglePath:       tmpDir,
+				GoogleBetaPath:   tmpDir,
+				Remote:           tmpDir,
+				GitTimeout:       "5 minutes",
+			},
+		},
+		"GitTimeout negative": {
+			expectError: true,
+			config: &Config{
+				MagicModulesPath: tmpDir,
+				GooglePath:       tmpDir,
+				GoogleBetaPath:   tmpDir,
+				Remote:           tmpDir,
+				GitTimeout:       "-1m",
+			},
+		},
 		"Remote unset": {
 			expectError: true,
 			config: &Config{
@@ -83,6 +132,58 @@ func TestConfig_validate(t *testing.T) {
 				GoogleBetaPath:   tmpDir,
 			},
 		},
+		"Targets set without GooglePath and GoogleBetaPath": {
+			config: &Config{
+				Remote: tmpDir,
+				Targets: []Target{
+					{Name: "ga", Repo: GA_REPO_NAME, Path: tmpDir},
+					{Name: "tgc", Repo: "terraform-google-conversion", Path: tmpDir, Remote: "origin"},
+				},
+			},
+		},
+		"Targets with remote but no top-level Remote": {
+			config: &Config{
+				Targets: []Target{
+					{Name: "tgc", Repo: "terraform-google-conversion", Path: tmpDir, Remote: "origin"},
+				},
+			},
+		},
+		"Targets without remote": {
+			expectError: true,
+			config: &Config{
+				Targets: []Target{
+					{Name: "tgc", Repo: "terraform-google-conversion", Path: tmpDir},
+				},
+			},
+		},
+		"Targets with duplicate names": {
+			expectError: true,
+			config: &Config{
+				Remote: tmpDir,
+				Targets: []Target{
+					{Name: "ga", Repo: GA_REPO_NAME, Path: tmpDir},
+					{Name: "ga", Repo: BETA_REPO_NAME, Path: tmpDir},
+				},
+			},
+		},
+		"Targets with missing repo and bad path": {
+			expectError: true,
+			config: &Config{
+				Remote: tmpDir,
+				Targets: []Target{
+					{Name: "tgc", Path: nonExistentDir},
+				},
+			},
+		},
+		"Targets with only one template set": {
+			expectError: true,
+			config: &Config{
+				Remote: tmpDir,
+				Targets: []Target{
+					{Name: "tgc", Repo: "terraform-google-conversion", Path: tmpDir, ChangelogTemplate: "changelog.tmpl"},
+				},
+			},
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -95,6 +196,9 @@ func TestConfig_validate(t *testing.T) {
 				}
 				// error is expected but we don't assert what error
 			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
 		})
 	}
 }
@@ -118,7 +222,7 @@ func TestConfig_LoadConfigFromFile(t *testing.T) {
 }`, tmpDir, tmpDir, tmpDir, remote, owner) // paths are all valid
 
 	// Make a test fixture that contains paths to existing directories
-	f, err := os.Create(tmpDir + CONFIG_FILE_NAME)
+	f, err := os.Create(filepath.Join(tmpDir, CONFIG_FILE_NAME))
 	if err != nil {
 		t.Fatalf("error creating temporary %s file: %s", CONFIG_FILE_NAME, err)
 	}
@@ -134,9 +238,10 @@ func TestConfig_LoadConfigFromFile(t *testing.T) {
 	// The function under test looks for a file called .tpg-cli-config.json located at $HOME
 	// so we need to temporarily make HOME the path to our temp folder.
 	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // empty, so the file in HOME is used
 
 	// Act
-	c, err := LoadConfigFromFile()
+	c, err := LoadConfigFromFile("", "")
 
 	// Assert
 	if err != nil {
@@ -159,3 +264,177 @@ func TestConfig_LoadConfigFromFile(t *testing.T) {
 		t.Fatalf("unexpected value of RemoteOwner, want %s, got %s", owner, c.RemoteOwner)
 	}
 }
+
+// writeConfigFile writes a config file to the given path, creating any missing directories
+func writeConfigFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("error creating directory for config file: %s", err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("error writing config file %s: %s", path, err)
+	}
+}
+
+// TestConfig_LoadConfigFromFile_locations checks which config file is loaded when config files
+// exist in several locations
+func TestConfig_LoadConfigFromFile_locations(t *testing.T) {
+
+	tmpDir := os.TempDir()
+	configJSON := func(remote string) string {
+		return fmt.Sprintf(`{
+	"googlePath": "%s",
+	"googleBetaPath": "%s",
+	"remote": "%s"
+}`, tmpDir, tmpDir, remote)
+	}
+
+	cases := map[string]struct {
+		flagPath       bool
+		envPath        bool
+		xdgFile        bool
+		expectedRemote string
+	}{
+		"HOME": {
+			expectedRemote: "home",
+		},
+		"XDG_CONFIG_HOME takes precedence over HOME": {
+			xdgFile:        true,
+			expectedRemote: "xdg",
+		},
+		"TPG_CLI_CONFIG takes precedence over XDG_CONFIG_HOME": {
+			envPath:        true,
+			xdgFile:        true,
+			expectedRemote: "env",
+		},
+		"path argument takes precedence over TPG_CLI_CONFIG": {
+			flagPath:       true,
+			envPath:        true,
+			xdgFile:        true,
+			expectedRemote: "flag",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			home := t.TempDir()
+			xdg := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", xdg)
+			t.Setenv("TPG_CLI_CONFIG", "")
+
+			writeConfigFile(t, filepath.Join(home, CONFIG_FILE_NAME), configJSON("home"))
+			if tc.xdgFile {
+				writeConfigFile(t, filepath.Join(xdg, XDG_CONFIG_DIR_NAME, XDG_CONFIG_FILE_NAME), configJSON("xdg"))
+			}
+			if tc.envPath {
+				p := filepath.Join(t.TempDir(), "env.json")
+				writeConfigFile(t, p, configJSON("env"))
+				t.Setenv("TPG_CLI_CONFIG", p)
+			}
+			path := ""
+			if tc.flagPath {
+				path = filepath.Join(t.TempDir(), "flag.json")
+				writeConfigFile(t, path, configJSON("flag"))
+			}
+
+			c, err := LoadConfigFromFile(path, "")
+			if err != nil {
+				t.Fatalf("unexpected error(s) encountered: %s", err)
+			}
+			if c.Remote != tc.expectedRemote {
+				t.Fatalf("unexpected value of Remote, want %s, got %s", tc.expectedRemote, c.Remote)
+			}
+		})
+	}
+}
+
+// TestConfig_LoadConfigFromFile_overrides checks that profiles and environment variables override values in the config file
+func TestConfig_LoadConfigFromFile_overrides(t *testing.T) {
+
+	tmpDir := os.TempDir()
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, fmt.Sprintf(`{
+	"googlePath": "%s",
+	"googleBetaPath": "%s",
+	"remote": "upstream",
+	"profiles": {
+		"fork-testing": {
+			"remote": "origin",
+			"remoteOwner": "sarahcorp"
+		}
+	}
+}`, tmpDir, tmpDir))
+
+	cases := map[string]struct {
+		profile             string
+		env                 map[string]string
+		expectedRemote      string
+		expectedRemoteOwner string
+		expectedMaintainers []string
+		expectError         bool
+	}{
+		"no profile": {
+			expectedRemote:      "upstream",
+			expectedRemoteOwner: "hashicorp",
+		},
+		"profile from argument": {
+			profile:             "fork-testing",
+			expectedRemote:      "origin",
+			expectedRemoteOwner: "sarahcorp",
+		},
+		"profile from environment variable": {
+			env:                 map[string]string{"TPG_CLI_PROFILE": "fork-testing"},
+			expectedRemote:      "origin",
+			expectedRemoteOwner: "sarahcorp",
+		},
+		"environment variables take precedence over profile": {
+			profile: "fork-testing",
+			env: map[string]string{
+				"TPG_CLI_REMOTE":      "fork",
+				"TPG_CLI_MAINTAINERS": "maintainer-1, maintainer-2",
+			},
+			expectedRemote:      "fork",
+			expectedRemoteOwner: "sarahcorp",
+			expectedMaintainers: []string{"maintainer-1", "maintainer-2"},
+		},
+		"missing profile": {
+			profile:     "doesnt-exist",
+			expectError: true,
+		},
+		"environment variables are validated": {
+			env:         map[string]string{"TPG_CLI_GOOGLE_PATH": "/User/doesntexist/path/to/nowhere"},
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			t.Setenv("TPG_CLI_PROFILE", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			c, err := LoadConfigFromFile(path, tc.profile)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if err != nil {
+				return
+			}
+
+			if c.Remote != tc.expectedRemote {
+				t.Fatalf("unexpected value of Remote, want %s, got %s", tc.expectedRemote, c.Remote)
+			}
+			if c.RemoteOwner != tc.expectedRemoteOwner {
+				t.Fatalf("unexpected value of RemoteOwner, want %s, got %s", tc.expectedRemoteOwner, c.RemoteOwner)
+			}
+			if strings.Join(c.Maintainers, ",") != strings.Join(tc.expectedMaintainers, ",") {
+				t.Fatalf("unexpected value of Maintainers, want %v, got %v", tc.expectedMaintainers, c.Maintainers)
+			}
+		})
+	}
+}
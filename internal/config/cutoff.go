@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cutoff is a weekly deadline for commits to be included in a release, e.g. "Monday 17:00".
+// A timezone can be added, e.g. "Monday 17:00 America/Los_Angeles", otherwise local time is used.
+type Cutoff struct {
+	Weekday  time.Weekday
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+// ParseCutoff parses a cutoff in the format "<weekday> <HH:MM> [timezone]"
+func ParseCutoff(s string) (Cutoff, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Cutoff{}, fmt.Errorf("cutoff %q should be in the format \"<weekday> <HH:MM> [timezone]\", e.g. \"Monday 17:00 America/Los_Angeles\"", s)
+	}
+
+	c := Cutoff{Location: time.Local}
+
+	found := false
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(fields[0], d.String()) || strings.EqualFold(fields[0], d.String()[:3]) {
+			c.Weekday = d
+			found = true
+		}
+	}
+	if !found {
+		return Cutoff{}, fmt.Errorf("cutoff %q doesn't start with a day of the week, e.g. Monday", s)
+	}
+
+	t, err := time.Parse("15:04", fields[1])
+	if err != nil {
+		return Cutoff{}, fmt.Errorf("cutoff %q doesn't contain a time in format HH:MM, e.g. 17:00", s)
+	}
+	c.Hour, c.Minute = t.Hour(), t.Minute()
+
+	if len(fields) == 3 {
+		loc, err := time.LoadLocation(fields[2])
+		if err != nil {
+			return Cutoff{}, fmt.Errorf("cutoff %q has an unknown timezone: %w", s, err)
+		}
+		c.Location = loc
+	}
+	return c, nil
+}
+
+// Previous returns the most recent occurrence of the cutoff at or before t
+func (c Cutoff) Previous(t time.Time) time.Time {
+	t = t.In(c.Location)
+	daysSince := (int(t.Weekday()) - int(c.Weekday) + 7) % 7
+	cutoff := time.Date(t.Year(), t.Month(), t.Day()-daysSince, c.Hour, c.Minute, 0, 0, c.Location)
+	if cutoff.After(t) {
+		cutoff = cutoff.AddDate(0, 0, -7)
+	}
+	return cutoff
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCutoff(t *testing.T) {
+	cases := map[string]struct {
+		cutoff      string
+		expected    Cutoff
+		expectError bool
+	}{
+		"weekday and time": {
+			cutoff:   "Monday 17:00",
+			expected: Cutoff{Weekday: time.Monday, Hour: 17, Location: time.Local},
+		},
+		"short weekday, time and timezone": {
+			cutoff:   "tue 09:30 UTC",
+			expected: Cutoff{Weekday: time.Tuesday, Hour: 9, Minute: 30, Location: time.UTC},
+		},
+		"missing time": {
+			cutoff:      "Monday",
+			expectError: true,
+		},
+		"bad weekday": {
+			cutoff:      "Someday 17:00",
+			expectError: true,
+		},
+		"bad time": {
+			cutoff:      "Monday 5pm",
+			expectError: true,
+		},
+		"bad timezone": {
+			cutoff:      "Monday 17:00 Nowhere/Special",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ParseCutoff(tc.cutoff)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if !tc.expectError && (got.Weekday != tc.expected.Weekday || got.Hour != tc.expected.Hour || got.Minute != tc.expected.Minute || got.Location.String() != tc.expected.Location.String()) {
+				t.Fatalf("wanted %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCutoff_Previous(t *testing.T) {
+	c := Cutoff{Weekday: time.Monday, Hour: 17, Location: time.UTC}
+
+	cases := map[string]struct {
+		now      time.Time
+		expected time.Time
+	}{
+		"later in the week": {
+			now:      time.Date(2024, 10, 17, 12, 0, 0, 0, time.UTC), // Thursday
+			expected: time.Date(2024, 10, 14, 17, 0, 0, 0, time.UTC),
+		},
+		"same day after the cutoff": {
+			now:      time.Date(2024, 10, 14, 18, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 10, 14, 17, 0, 0, 0, time.UTC),
+		},
+		"same day before the cutoff": {
+			now:      time.Date(2024, 10, 14, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 10, 7, 17, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			if got := c.Previous(tc.now); !got.Equal(tc.expected) {
+				t.Fatalf("wanted %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// schema is the JSON Schema describing config files, which editors can use for validation and completion
+//
+//go:embed schema.json
+var schema []byte
+
+// Schema returns the JSON Schema describing config files
+func Schema() []byte {
+	return schema
+}
+
+var FORMAT_JSON = "json"
+var FORMAT_YAML = "yaml"
+var FORMAT_TOML = "toml"
+
+// formatFromPath returns the format of a config file based on its extension. Files without
+// a recognised extension are treated as JSON.
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FORMAT_YAML
+	case ".toml":
+		return FORMAT_TOML
+	default:
+		return FORMAT_JSON
+	}
+}
+
+// toJSON converts the contents of a YAML or TOML config file to JSON, so all formats are decoded
+// and validated by the same code
+func toJSON(data []byte, format string) ([]byte, error) {
+	var v map[string]interface{}
+	switch format {
+	case FORMAT_YAML:
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+	case FORMAT_TOML:
+		if err := toml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+	if v == nil {
+		// An empty file
+		v = map[string]interface{}{}
+	}
+	return json.Marshal(v)
+}
+
+// fromJSON converts JSON to the format of a config file
+func fromJSON(data []byte, format string) ([]byte, error) {
+	if format == FORMAT_JSON {
+		var b bytes.Buffer
+		if err := json.Indent(&b, data, "", "    "); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	switch format {
+	case FORMAT_YAML:
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+	case FORMAT_TOML:
+		if err := toml.NewEncoder(&b).Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// DecodeFile decodes a JSON, YAML or TOML file into v, based on the file's extension. Keys that don't
+// correspond to a field of v are reported as errors.
+func DecodeFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", path, err)
+	}
+	data, err = toJSON(data, formatFromPath(path))
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if err := decodeStrict(data, v); err != nil {
+		return fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// unknownFieldRE matches the error returned by encoding/json when DisallowUnknownFields is set
+var unknownFieldRE = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+
+// decodeStrict decodes JSON into v, returning an error that names any keys that don't correspond
+// to a config value instead of silently ignoring them
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		// encoding/json matches keys case-insensitively, so keys like "remoteowner" need to be found separately
+		return checkKeyCase(data, jsonKeys(reflect.TypeOf(v)))
+	}
+
+	matches := unknownFieldRE.FindStringSubmatch(err.Error())
+	if matches == nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fmt.Errorf("%s should be a %s, got a %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return err
+	}
+	key := matches[1]
+	if suggestion := closestKey(key, jsonKeys(reflect.TypeOf(v))); suggestion != "" {
+		return fmt.Errorf("unknown key %q, did you mean %q?", key, suggestion)
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+// checkKeyCase returns an error if a key in the JSON document only matches a known key when ignoring case.
+// Keys of the profiles map are names chosen by the user, so are not checked.
+func checkKeyCase(data []byte, known []string) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	exact := map[string]bool{}
+	for _, k := range known {
+		exact[k] = true
+	}
+
+	var walk func(v interface{}, userKeys bool) error
+	walk = func(v interface{}, userKeys bool) error {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if !userKeys && !exact[k] {
+					return fmt.Errorf("unknown key %q, did you mean %q?", k, closestKey(k, known))
+				}
+				if err := walk(child, !userKeys && k == "profiles"); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, child := range v {
+				if err := walk(child, false); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(doc, false)
+}
+
+// jsonKeys returns the JSON keys of a struct, including those of nested and embedded structs
+func jsonKeys(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name != "" {
+			keys = append(keys, name)
+		}
+		keys = append(keys, jsonKeys(f.Type)...)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// closestKey returns the key that is most similar to the unknown key, if any are similar enough
+// to be a likely typo
+func closestKey(unknown string, keys []string) string {
+	best := ""
+	bestDistance := len(unknown)/3 + 1
+	for _, k := range keys {
+		if d := editDistance(strings.ToLower(unknown), strings.ToLower(k)); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
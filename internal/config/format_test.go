@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConfig_LoadConfigFromFile_formats checks that equivalent config files in each format are loaded the same way
+func TestConfig_LoadConfigFromFile_formats(t *testing.T) {
+
+	tmpDir := os.TempDir()
+
+	cases := map[string]struct {
+		fileName string
+		contents string
+	}{
+		"JSON": {
+			fileName: "config.json",
+			contents: fmt.Sprintf(`{
+	"googlePath": "%s",
+	"googleBetaPath": "%s",
+	"remote": "upstream",
+	"maintainers": ["maintainer-1"],
+	"profiles": {"fork": {"remoteOwner": "someone"}}
+}`, tmpDir, tmpDir),
+		},
+		"YAML": {
+			fileName: "config.yaml",
+			contents: fmt.Sprintf(`googlePath: %s
+googleBetaPath: %s
+remote: upstream
+maintainers:
+  - maintainer-1
+profiles:
+  fork:
+    remoteOwner: someone
+`, tmpDir, tmpDir),
+		},
+		"TOML": {
+			fileName: "config.toml",
+			contents: fmt.Sprintf(`googlePath = %q
+googleBetaPath = %q
+remote = "upstream"
+maintainers = ["maintainer-1"]
+
+[profiles.fork]
+remoteOwner = "someone"
+`, tmpDir, tmpDir),
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			writeConfigFile(t, path, tc.contents)
+
+			c, err := LoadConfigFromFile(path, "fork")
+			if err != nil {
+				t.Fatalf("unexpected error(s) encountered: %s", err)
+			}
+			if c.GooglePath != tmpDir || c.Remote != "upstream" || c.RemoteOwner != "someone" {
+				t.Fatalf("config loaded with unexpected values: %#v", c)
+			}
+			if len(c.Maintainers) != 1 || c.Maintainers[0] != "maintainer-1" {
+				t.Fatalf("unexpected value of Maintainers, got %v", c.Maintainers)
+			}
+		})
+	}
+}
+
+// TestConfig_LoadConfigFromFile_gitTimeout checks that gitTimeout can be a number of seconds, which YAML and TOML
+// decode as an integer
+func TestConfig_LoadConfigFromFile_gitTimeout(t *testing.T) {
+	tmpDir := os.TempDir()
+
+	cases := map[string]struct {
+		fileName        string
+		contents        string
+		expectedTimeout time.Duration
+	}{
+		"YAML zero": {
+			fileName:        "config.yaml",
+			contents:        fmt.Sprintf("googlePath: %s\ngoogleBetaPath: %s\nremote: upstream\ngitTimeout: 0\n", tmpDir, tmpDir),
+			expectedTimeout: 0,
+		},
+		"TOML seconds": {
+			fileName:        "config.toml",
+			contents:        fmt.Sprintf("googlePath = %q\ngoogleBetaPath = %q\nremote = \"upstream\"\ngitTimeout = 300\n", tmpDir, tmpDir),
+			expectedTimeout: 5 * time.Minute,
+		},
+		"JSON duration": {
+			fileName:        "config.json",
+			contents:        fmt.Sprintf(`{"googlePath": %q, "googleBetaPath": %q, "remote": "upstream", "gitTimeout": "90s"}`, tmpDir, tmpDir),
+			expectedTimeout: 90 * time.Second,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			writeConfigFile(t, path, tc.contents)
+
+			c, err := LoadConfigFromFile(path, "")
+			if err != nil {
+				t.Fatalf("unexpected error(s) encountered: %s", err)
+			}
+			if got := c.GetGitTimeout(); got != tc.expectedTimeout {
+				t.Fatalf("wanted gitTimeout %s, got %s", tc.expectedTimeout, got)
+			}
+		})
+	}
+}
+
+// TestConfig_LoadConfigFromFile_unknownKeys checks that typos in config files are reported rather than ignored
+func TestConfig_LoadConfigFromFile_unknownKeys(t *testing.T) {
+
+	tmpDir := os.TempDir()
+
+	cases := map[string]struct {
+		fileName      string
+		contents      string
+		expectedError string
+	}{
+		"JSON typo": {
+			fileName:      "config.json",
+			contents:      fmt.Sprintf(`{"googlPath": "%s", "googleBetaPath": "%s", "remote": "upstream"}`, tmpDir, tmpDir),
+			expectedError: `unknown key "googlPath", did you mean "googlePath"?`,
+		},
+		"YAML typo in a target": {
+			fileName:      "config.yaml",
+			contents:      fmt.Sprintf("remote: upstream\ntargets:\n  - name: ga\n    repo: terraform-provider-google\n    path: %s\n    brnachTemplate: release-{version}\n", tmpDir),
+			expectedError: `unknown key "brnachTemplate", did you mean "branchTemplate"?`,
+		},
+		"TOML typo in a profile": {
+			fileName:      "config.toml",
+			contents:      fmt.Sprintf("googlePath = %q\ngoogleBetaPath = %q\nremote = \"upstream\"\n[profiles.fork]\nremoteowner = \"someone\"\n", tmpDir, tmpDir),
+			expectedError: `unknown key "remoteowner", did you mean "remoteOwner"?`,
+		},
+		"unrelated key": {
+			fileName:      "config.json",
+			contents:      fmt.Sprintf(`{"googlePath": "%s", "googleBetaPath": "%s", "remote": "upstream", "favouriteColour": "blue"}`, tmpDir, tmpDir),
+			expectedError: `unknown key "favouriteColour"`,
+		},
+		"wrong type": {
+			fileName:      "config.yaml",
+			contents:      fmt.Sprintf("googlePath: %s\ngoogleBetaPath: %s\nremote: upstream\nmaintainers: maintainer-1\n", tmpDir, tmpDir),
+			expectedError: "maintainers should be a []string, got a string",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			writeConfigFile(t, path, tc.contents)
+
+			_, err := LoadConfigFromFile(path, "fork")
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("wanted error containing %q, got: %s", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+// TestWriteConfigFile_formats checks that config files written in each format can be loaded again
+func TestWriteConfigFile_formats(t *testing.T) {
+
+	tmpDir := os.TempDir()
+	want := &Config{
+		GooglePath:     tmpDir,
+		GoogleBetaPath: tmpDir,
+		Remote:         "upstream",
+		RemoteOwner:    "hashicorp",
+		Maintainers:    []string{"maintainer-1", "maintainer-2"},
+		ChangelogLinks: CHANGELOG_LINKS_BOTH,
+	}
+
+	for _, name := range []string{"config.json", "config.yaml", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := WriteConfigFile(path, want); err != nil {
+				t.Fatalf("unexpected error writing config: %s", err)
+			}
+
+			got, err := LoadConfigFromFile(path, "")
+			if err != nil {
+				t.Fatalf("unexpected error loading written config: %s", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("wanted %#v, got %#v", want, got)
+			}
+		})
+	}
+}
+
+// TestSchema checks the JSON Schema describes every config value, so it stays in sync with the Config and Target structs
+func TestSchema(t *testing.T) {
+	var s struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema(), &s); err != nil {
+		t.Fatalf("schema isn't valid JSON: %s", err)
+	}
+
+	cases := map[string]reflect.Type{
+		"config": reflect.TypeOf(Config{}),
+		"target": reflect.TypeOf(Target{}),
+	}
+	for def, typ := range cases {
+		var properties []string
+		for p := range s.Defs[def].Properties {
+			properties = append(properties, p)
+		}
+		sort.Strings(properties)
+
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+
+		if !reflect.DeepEqual(properties, fields) {
+			t.Fatalf("schema properties of %s don't match the struct's fields:\n\tschema: %v\n\tstruct: %v", def, properties, fields)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var DEFAULT_BRANCH_TEMPLATE = "release-{version}"
+var DEFAULT_TRUNK_BRANCH = "main"
+var DEFAULT_TAG_TEMPLATE = "v{version}"
+
+// VERSION_PLACEHOLDER is replaced with a version, without the v prefix, in branch and tag templates
+var VERSION_PLACEHOLDER = "{version}"
+
+// Target describes a downstream repository that releases are made for using the same process,
+// e.g. terraform-provider-google, terraform-provider-google-beta or terraform-google-conversion
+type Target struct {
+	// Name is used to select the target, e.g. via the -target flag
+	Name string `json:"name"`
+	// Repo is the name of the GitHub repository
+	Repo string `json:"repo"`
+	// Owner is the owner of the GitHub repository, defaulting to remoteOwner
+	Owner string `json:"owner,omitempty"`
+	// Path is the (absolute) path to the local clone of the repository
+	Path string `json:"path"`
+	// Remote is the name of the remote in the local clone that corresponds to the official repository, defaulting to remote
+	Remote string `json:"remote,omitempty"`
+	// BranchTemplate is used to name release branches. {version} is replaced with the version without the v prefix.
+	BranchTemplate string `json:"branchTemplate,omitempty"`
+	// TrunkBranch is the branch that releases are cut from, defaulting to main
+	TrunkBranch string `json:"trunkBranch,omitempty"`
+	// TagTemplate is the format of release tags. {version} is replaced with the version without the v prefix.
+	TagTemplate string `json:"tagTemplate,omitempty"`
+	// ChangelogTemplate and ReleaseNoteTemplate are paths to the templates passed to changelog-gen,
+	// defaulting to the templates in magic-modules
+	ChangelogTemplate   string `json:"changelogTemplate,omitempty"`
+	ReleaseNoteTemplate string `json:"releaseNoteTemplate,omitempty"`
+}
+
+// GetTargets returns the release targets, with defaults applied.
+//
+// If no targets are configured, targets named ga and beta are created from googlePath and googleBetaPath.
+func (c *Config) GetTargets() []Target {
+	targets := c.Targets
+	if len(targets) == 0 {
+		targets = []Target{
+			{Name: "ga", Repo: GA_REPO_NAME, Path: c.GooglePath},
+			{Name: "beta", Repo: BETA_REPO_NAME, Path: c.GoogleBetaPath},
+		}
+	}
+
+	withDefaults := make([]Target, len(targets))
+	for i, t := range targets {
+		if t.Owner == "" {
+			t.Owner = c.RemoteOwner
+		}
+		if t.Remote == "" {
+			t.Remote = c.Remote
+		}
+		if t.BranchTemplate == "" {
+			t.BranchTemplate = DEFAULT_BRANCH_TEMPLATE
+		}
+		if t.TrunkBranch == "" {
+			t.TrunkBranch = DEFAULT_TRUNK_BRANCH
+		}
+		if t.TagTemplate == "" {
+			t.TagTemplate = DEFAULT_TAG_TEMPLATE
+		}
+		withDefaults[i] = t
+	}
+	return withDefaults
+}
+
+// GetTarget returns the release target with the given name, with defaults applied
+func (c *Config) GetTarget(name string) (*Target, error) {
+	targets := c.GetTargets()
+	for i := range targets {
+		if targets[i].Name == name {
+			return &targets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no release target called %q in config, expected one of: %s", name, strings.Join(c.GetTargetNames(), ", "))
+}
+
+// GetTargetNames returns the names of the release targets
+func (c *Config) GetTargetNames() []string {
+	var names []string
+	for _, t := range c.GetTargets() {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// BranchName returns the name of the release branch for a version in format v1.2.3
+func (t *Target) BranchName(releaseVersion string) string {
+	version := strings.TrimPrefix(releaseVersion, "v") // Remove prefix v1.2.3 => 1.2.3
+	return strings.ReplaceAll(t.BranchTemplate, VERSION_PLACEHOLDER, version)
+}
+
+// TagName returns the name of the release tag for a version in format v1.2.3
+func (t *Target) TagName(releaseVersion string) string {
+	version := strings.TrimPrefix(releaseVersion, "v") // Remove prefix v1.2.3 => 1.2.3
+	return strings.ReplaceAll(t.TagTemplate, VERSION_PLACEHOLDER, version)
+}
+
+// VersionFromTag returns the version in format v1.2.3 that a release tag was created for
+func (t *Target) VersionFromTag(tag string) (string, error) {
+	prefix, suffix, _ := strings.Cut(t.TagTemplate, VERSION_PLACEHOLDER)
+	if !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) || len(tag) <= len(prefix)+len(suffix) {
+		return "", fmt.Errorf("tag %q doesn't match the tag template %q of target %q", tag, t.TagTemplate, t.Name)
+	}
+	return "v" + strings.TrimSuffix(strings.TrimPrefix(tag, prefix), suffix), nil
+}
+
+func (c *Config) validateTargets() []error {
+	var errs []error
+
+	if len(c.Targets) == 0 {
+		// Without a list of targets, googlePath and googleBetaPath define the ga and beta targets
+		if c.GooglePath == "" {
+			errs = append(errs, errors.New("error in loaded config: googlePath is empty/missing"))
+		} else {
+			_, err := os.ReadDir(c.GooglePath)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error opening googlePath path: %w", err))
+			}
+		}
+
+		if c.GoogleBetaPath == "" {
+			errs = append(errs, errors.New("error in loaded config: googleBetaPath is empty/missing"))
+		} else {
+			_, err := os.ReadDir(c.GoogleBetaPath)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error opening googleBetaPath path: %w", err))
+			}
+		}
+
+		if c.Remote == "" {
+			errs = append(errs, errors.New("error in loaded config: remote is empty/missing"))
+		}
+		for _, t := range c.GetTargets() {
+			errs = append(errs, t.validateConventions()...)
+		}
+		return errs
+	}
+
+	seen := map[string]bool{}
+	for i, t := range c.GetTargets() {
+		if t.Name == "" {
+			errs = append(errs, fmt.Errorf("error in loaded config: targets[%d] has an empty/missing name", i))
+		} else if seen[t.Name] {
+			errs = append(errs, fmt.Errorf("error in loaded config: there is more than one target called %q", t.Name))
+		}
+		seen[t.Name] = true
+
+		if t.Repo == "" {
+			errs = append(errs, fmt.Errorf("error in loaded config: target %q has an empty/missing repo", t.Name))
+		}
+		if t.Path == "" {
+			errs = append(errs, fmt.Errorf("error in loaded config: target %q has an empty/missing path", t.Name))
+		} else if _, err := os.ReadDir(t.Path); err != nil {
+			errs = append(errs, fmt.Errorf("error opening path of target %q: %w", t.Name, err))
+		}
+		if t.Remote == "" {
+			errs = append(errs, fmt.Errorf("error in loaded config: target %q has no remote, and remote is empty/missing", t.Name))
+		}
+		if (t.ChangelogTemplate == "") != (t.ReleaseNoteTemplate == "") {
+			errs = append(errs, fmt.Errorf("error in loaded config: target %q should set both changelogTemplate and releaseNoteTemplate, or neither", t.Name))
+		}
+		errs = append(errs, t.validateConventions()...)
+	}
+	return errs
+}
+
+// validateConventions checks the target's branch and tag names are usable as git refs
+func (t *Target) validateConventions() []error {
+	var errs []error
+
+	if !isValidRefName(t.TrunkBranch) {
+		errs = append(errs, fmt.Errorf("error in loaded config: target %q has trunkBranch %q, which isn't a valid branch name", t.Name, t.TrunkBranch))
+	}
+
+	templates := []struct{ field, template string }{
+		{"branchTemplate", t.BranchTemplate},
+		{"tagTemplate", t.TagTemplate},
+	}
+	for _, tt := range templates {
+		field, template := tt.field, tt.template
+		if strings.Count(template, VERSION_PLACEHOLDER) != 1 {
+			errs = append(errs, fmt.Errorf("error in loaded config: target %q has %s %q, which should contain %s exactly once", t.Name, field, template, VERSION_PLACEHOLDER))
+			continue
+		}
+		example := strings.ReplaceAll(template, VERSION_PLACEHOLDER, "1.2.3")
+		if !isValidRefName(example) {
+			errs = append(errs, fmt.Errorf("error in loaded config: target %q has %s %q, which creates invalid names like %q", t.Name, field, template, example))
+		}
+	}
+
+	return errs
+}
+
+// isValidRefName approximates the rules of `git check-ref-format` for a branch or tag name
+func isValidRefName(name string) bool {
+	if name == "" || name == "@" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") ||
+		strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock") ||
+		strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") ||
+		strings.Contains(name, "/.") || strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestConfig_GetTargets(t *testing.T) {
+	cases := map[string]struct {
+		config   *Config
+		expected []Target
+	}{
+		"targets are created from googlePath and googleBetaPath": {
+			config: &Config{
+				GooglePath:     "/path/to/ga",
+				GoogleBetaPath: "/path/to/beta",
+				Remote:         "upstream",
+				RemoteOwner:    "hashicorp",
+			},
+			expected: []Target{
+				{Name: "ga", Repo: GA_REPO_NAME, Owner: "hashicorp", Path: "/path/to/ga", Remote: "upstream", BranchTemplate: DEFAULT_BRANCH_TEMPLATE, TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: DEFAULT_TAG_TEMPLATE},
+				{Name: "beta", Repo: BETA_REPO_NAME, Owner: "hashicorp", Path: "/path/to/beta", Remote: "upstream", BranchTemplate: DEFAULT_BRANCH_TEMPLATE, TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: DEFAULT_TAG_TEMPLATE},
+			},
+		},
+		"configured targets have defaults applied": {
+			config: &Config{
+				// Ignored when targets are set
+				GooglePath:  "/path/to/ga",
+				Remote:      "upstream",
+				RemoteOwner: "hashicorp",
+				Targets: []Target{
+					{Name: "tgc", Repo: "terraform-google-conversion", Path: "/path/to/tgc"},
+					{Name: "fork", Repo: GA_REPO_NAME, Owner: "someone", Path: "/path/to/fork", Remote: "origin", BranchTemplate: "release/v{version}", TrunkBranch: "develop", TagTemplate: "release-{version}"},
+				},
+			},
+			expected: []Target{
+				{Name: "tgc", Repo: "terraform-google-conversion", Owner: "hashicorp", Path: "/path/to/tgc", Remote: "upstream", BranchTemplate: DEFAULT_BRANCH_TEMPLATE, TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: DEFAULT_TAG_TEMPLATE},
+				{Name: "fork", Repo: GA_REPO_NAME, Owner: "someone", Path: "/path/to/fork", Remote: "origin", BranchTemplate: "release/v{version}", TrunkBranch: "develop", TagTemplate: "release-{version}"},
+			},
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			targets := tc.config.GetTargets()
+			if len(targets) != len(tc.expected) {
+				t.Fatalf("wanted %d targets, got %d: %v", len(tc.expected), len(targets), targets)
+			}
+			for i := range targets {
+				if targets[i] != tc.expected[i] {
+					t.Fatalf("wanted target %v, got %v", tc.expected[i], targets[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_GetTarget(t *testing.T) {
+	c := &Config{GooglePath: "/path/to/ga", GoogleBetaPath: "/path/to/beta"}
+
+	target, err := c.GetTarget("beta")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if target.Repo != BETA_REPO_NAME || target.Path != "/path/to/beta" {
+		t.Fatalf("got the wrong target: %v", target)
+	}
+
+	if _, err := c.GetTarget("tgc"); err == nil {
+		t.Fatal("expected error for a target that isn't configured but got none")
+	}
+}
+
+func TestTarget_BranchName(t *testing.T) {
+	cases := map[string]struct {
+		template string
+		expected string
+	}{
+		"default template": {
+			template: DEFAULT_BRANCH_TEMPLATE,
+			expected: "release-4.23.0",
+		},
+		"custom template": {
+			template: "release/v{version}",
+			expected: "release/v4.23.0",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			target := Target{BranchTemplate: tc.template}
+			if got := target.BranchName("v4.23.0"); got != tc.expected {
+				t.Fatalf("wanted %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTarget_TagName(t *testing.T) {
+	cases := map[string]struct {
+		template string
+		expected string
+	}{
+		"default template": {
+			template: DEFAULT_TAG_TEMPLATE,
+			expected: "v4.23.0",
+		},
+		"custom template": {
+			template: "tpg/{version}-release",
+			expected: "tpg/4.23.0-release",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			target := Target{TagTemplate: tc.template}
+			got := target.TagName("v4.23.0")
+			if got != tc.expected {
+				t.Fatalf("wanted %q, got %q", tc.expected, got)
+			}
+
+			// Converting back should give the original version
+			version, err := target.VersionFromTag(got)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if version != "v4.23.0" {
+				t.Fatalf("wanted v4.23.0 from tag %q, got %q", got, version)
+			}
+		})
+	}
+}
+
+func TestTarget_VersionFromTag_mismatch(t *testing.T) {
+	target := Target{Name: "ga", TagTemplate: "tpg/{version}"}
+	for _, tag := range []string{"v4.23.0", "tpg/", "other/4.23.0"} {
+		if _, err := target.VersionFromTag(tag); err == nil {
+			t.Fatalf("expected error for tag %q but got none", tag)
+		}
+	}
+}
+
+func TestTarget_validateConventions(t *testing.T) {
+	cases := map[string]struct {
+		target      Target
+		expectError bool
+	}{
+		"defaults": {
+			target: Target{BranchTemplate: DEFAULT_BRANCH_TEMPLATE, TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: DEFAULT_TAG_TEMPLATE},
+		},
+		"custom conventions": {
+			target: Target{BranchTemplate: "release/v{version}", TrunkBranch: "develop", TagTemplate: "tpg-v{version}"},
+		},
+		"branch template without placeholder": {
+			target:      Target{BranchTemplate: "release", TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: DEFAULT_TAG_TEMPLATE},
+			expectError: true,
+		},
+		"tag template with placeholder twice": {
+			target:      Target{BranchTemplate: DEFAULT_BRANCH_TEMPLATE, TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: "{version}-{version}"},
+			expectError: true,
+		},
+		"branch template creating invalid names": {
+			target:      Target{BranchTemplate: "release {version}", TrunkBranch: DEFAULT_TRUNK_BRANCH, TagTemplate: DEFAULT_TAG_TEMPLATE},
+			expectError: true,
+		},
+		"invalid trunk branch": {
+			target:      Target{BranchTemplate: DEFAULT_BRANCH_TEMPLATE, TrunkBranch: "main..", TagTemplate: DEFAULT_TAG_TEMPLATE},
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			errs := tc.target.validateConventions()
+			if len(errs) > 0 && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %v", errs)
+			}
+			if len(errs) == 0 && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+}
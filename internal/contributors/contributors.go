@@ -0,0 +1,84 @@
+package contributors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+)
+
+// KNOWN_BOTS are automated accounts that open or author PRs but aren't reported as bots by the GitHub API
+var KNOWN_BOTS = []string{"modular-magician"}
+
+// Finder collects the authors of the pull requests included in a release
+type Finder struct {
+	GitHub github.PullRequestFinder
+	// Owner and Repo identify the downstream provider repository the commits belong to
+	Owner string
+	Repo  string
+	// Maintainers are excluded from the list of contributors
+	Maintainers []string
+}
+
+// Find returns the de-duplicated GitHub usernames of the authors of the PRs that the commits came from, sorted alphabetically.
+//
+// Commits generated from magic-modules are credited to the author of the upstream magic-modules PR,
+// and other commits are credited to the author of the downstream PR. Bots and maintainers are excluded. The lookups stop if ctx is cancelled.
+func (f *Finder) Find(ctx context.Context, commits []git.Commit) ([]string, error) {
+	excluded := map[string]bool{}
+	for _, l := range KNOWN_BOTS {
+		excluded[strings.ToLower(l)] = true
+	}
+	for _, l := range f.Maintainers {
+		excluded[strings.ToLower(l)] = true
+	}
+
+	seen := map[string]bool{}
+	var contributors []string
+	for _, c := range commits {
+		owner, repo, sha := f.Owner, f.Repo, c.Sha
+		if upstream := c.UpstreamSha(); upstream != "" {
+			owner, repo, sha = github.MAGIC_MODULES_OWNER, github.MAGIC_MODULES_REPO_NAME, upstream
+		}
+
+		prs, err := f.GitHub.PullRequestsForCommit(ctx, owner, repo, sha)
+		if err != nil {
+			return nil, err
+		}
+		pr, ok := github.MergedPullRequest(prs)
+		if !ok {
+			continue
+		}
+
+		login := pr.User.Login
+		key := strings.ToLower(login)
+		if login == "" || pr.User.IsBot() || strings.HasSuffix(key, "[bot]") || excluded[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		contributors = append(contributors, login)
+	}
+
+	sort.Slice(contributors, func(i, j int) bool {
+		return strings.ToLower(contributors[i]) < strings.ToLower(contributors[j])
+	})
+	return contributors, nil
+}
+
+// Section returns a section crediting the contributors that can be added alongside the changelog,
+// or an empty string if there are no contributors.
+func Section(contributors []string) string {
+	if len(contributors) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Thanks to our contributors:\n")
+	for _, c := range contributors {
+		b.WriteString(fmt.Sprintf("* @%s\n", c))
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package contributors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github/githubtest"
+)
+
+func mergedPR(login, userType string) []github.PullRequest {
+	return githubtest.MergedPR(github.PullRequest{User: github.User{Login: login, Type: userType}})
+}
+
+func TestFinder_Find(t *testing.T) {
+
+	gh := githubtest.FakeFinder{
+		"GoogleCloudPlatform/magic-modules/aaaaaaa":   mergedPR("Contributor-B", "User"),
+		"GoogleCloudPlatform/magic-modules/bbbbbbb":   mergedPR("contributor-a", "User"),
+		"GoogleCloudPlatform/magic-modules/ccccccc":   mergedPR("contributor-b", "User"),
+		"GoogleCloudPlatform/magic-modules/ddddddd":   mergedPR("dependabot[bot]", "Bot"),
+		"GoogleCloudPlatform/magic-modules/eeeeeee":   mergedPR("maintainer-1", "User"),
+		"GoogleCloudPlatform/magic-modules/fffffff":   {{User: github.User{Login: "unmerged", Type: "User"}}},
+		"hashicorp/terraform-provider-google/1234567": mergedPR("modular-magician", "User"),
+		"hashicorp/terraform-provider-google/7654321": mergedPR("downstream-contributor", "User"),
+	}
+
+	commits := []git.Commit{
+		{Sha: "1111111", Message: "Add field\n\n[upstream:aaaaaaa]\n\nSigned-off-by: Modular Magician"},
+		{Sha: "2222222", Message: "Fix bug\n\n[upstream:bbbbbbb]"},
+		{Sha: "3333333", Message: "Fix another bug\n\n[upstream:ccccccc]"},
+		{Sha: "4444444", Message: "Bump dependency\n\n[upstream:ddddddd]"},
+		{Sha: "5555555", Message: "Maintainer change\n\n[upstream:eeeeeee]"},
+		{Sha: "6666666", Message: "Unmerged change\n\n[upstream:fffffff]"},
+		{Sha: "1234567", Message: "Update CHANGELOG.md"},
+		{Sha: "7654321", Message: "Change made directly in the downstream repo"},
+	}
+
+	f := Finder{
+		GitHub:      gh,
+		Owner:       "hashicorp",
+		Repo:        "terraform-provider-google",
+		Maintainers: []string{"Maintainer-1"},
+	}
+
+	got, err := f.Find(context.Background(), commits)
+	if err != nil {
+		t.Fatalf("unexpected error(s) encountered: %v", err)
+	}
+
+	want := []string{"contributor-a", "Contributor-B", "downstream-contributor"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestSection(t *testing.T) {
+	if s := Section(nil); s != "" {
+		t.Fatalf("expected no section when there are no contributors, got %q", s)
+	}
+
+	want := "Thanks to our contributors:\n* @contributor-a\n* @contributor-b\n"
+	if s := Section([]string{"contributor-a", "contributor-b"}); s != want {
+		t.Fatalf("wanted %q, got %q", want, s)
+	}
+}
@@ -0,0 +1,248 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/changelog"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/setup"
+)
+
+type Status string
+
+const (
+	PASS Status = "pass"
+	WARN Status = "warn"
+	FAIL Status = "fail"
+)
+
+// Result describes the outcome of a single check
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Details string `json:"details"`
+}
+
+// Report contains the results of all the checks
+type Report struct {
+	Results []Result `json:"results"`
+	Passed  bool     `json:"passed"`
+}
+
+// UserFinder looks up the user that a GitHub token belongs to
+type UserFinder interface {
+	GetAuthenticatedUser(ctx context.Context) (github.User, []string, error)
+}
+
+// Doctor checks that the release environment is set up in the way that the release process expects
+type Doctor struct {
+	// Config is the loaded config, or nil if it couldn't be loaded
+	Config *config.Config
+	// ConfigErr is the error returned when loading config, if any
+	ConfigErr error
+
+	// GitHub is used to check the token. It is nil if no token could be found.
+	GitHub UserFinder
+	// TokenSource describes where the token came from
+	TokenSource string
+	// TokenErr is the error returned when finding the token, if any
+	TokenErr error
+
+	ChangelogExecutable string
+}
+
+// Run runs all the checks. Checks that depend on config are skipped if the config couldn't be loaded. The token check
+// stops if ctx is cancelled.
+func (d *Doctor) Run(ctx context.Context) Report {
+	r := Report{}
+
+	if d.ConfigErr != nil {
+		r.Results = append(r.Results, Result{Check: "config", Status: FAIL, Details: d.ConfigErr.Error()})
+	} else {
+		r.Results = append(r.Results, Result{Check: "config", Status: PASS, Details: "config file loaded and valid"})
+
+		targets := d.Config.GetTargets()
+		for i := range targets {
+			r.Results = append(r.Results, CheckProviderClone(&targets[i])...)
+		}
+		r.Results = append(r.Results, checkMagicModules(d.Config.MagicModulesPath))
+		for i := range targets {
+			r.Results = append(r.Results, checkTemplates(&targets[i], d.Config.MagicModulesPath))
+		}
+	}
+
+	r.Results = append(r.Results, checkToken(ctx, d.GitHub, d.TokenSource, d.TokenErr))
+	r.Results = append(r.Results, checkChangelogGen(d.ChangelogExecutable))
+	r.Results = append(r.Results, checkGit())
+
+	r.Passed = true
+	for _, res := range r.Results {
+		if res.Status == FAIL {
+			r.Passed = false
+		}
+	}
+	return r
+}
+
+// Table returns the results formatted as a table for printing to the terminal
+func (r Report) Table() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAILS")
+	for _, res := range r.Results {
+		// Multi-line details, e.g. from composite errors, are indented under the first line
+		details := strings.ReplaceAll(strings.TrimSpace(res.Details), "\n", "\n\t\t")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", res.Check, strings.ToUpper(string(res.Status)), details)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// JSON returns the results as an indented JSON document
+func (r Report) JSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CheckProviderClone checks the target's clone is a git repository for the expected repository,
+// and that the target's remote points at the official repository
+func CheckProviderClone(t *config.Target) []Result {
+	repoName, path := t.Repo, t.Path
+	cloneCheck := fmt.Sprintf("%s clone", repoName)
+	remoteCheck := fmt.Sprintf("%s remote", repoName)
+
+	gi := git.GitInteract{Dir: path}
+	if cmd, err := gi.IsRepository(); err != nil {
+		return []Result{
+			{Check: cloneCheck, Status: FAIL, Details: cmd.ErrorDescription(fmt.Sprintf("%s is not a git repository", path))},
+		}
+	}
+	remotes, cmd, err := gi.GetRemotes()
+	if err != nil {
+		return []Result{
+			{Check: cloneCheck, Status: FAIL, Details: cmd.ErrorDescription("error when listing remotes")},
+		}
+	}
+
+	results := []Result{}
+	if pointsAtRepo(remotes, repoName) {
+		results = append(results, Result{Check: cloneCheck, Status: PASS, Details: fmt.Sprintf("%s is a clone of %s", path, repoName)})
+	} else {
+		results = append(results, Result{Check: cloneCheck, Status: WARN, Details: fmt.Sprintf("no remote in %s points at a %s repository, check this is the right clone", path, repoName)})
+	}
+
+	url, ok := remotes[t.Remote]
+	if !ok {
+		return append(results, Result{Check: remoteCheck, Status: FAIL, Details: fmt.Sprintf("remote %q doesn't exist in %s", t.Remote, path)})
+	}
+	owner, repo, ok := setup.ParseGitHubRemote(url)
+	if !ok || !strings.EqualFold(owner, t.Owner) || !strings.EqualFold(repo, repoName) {
+		// The URL can contain a token, e.g. https://x-access-token:<token>@github.com/...
+		return append(results, Result{Check: remoteCheck, Status: FAIL, Details: fmt.Sprintf("remote %q points at %s, expected github.com/%s/%s", t.Remote, secrets.Redact(url), t.Owner, repoName)})
+	}
+	return append(results, Result{Check: remoteCheck, Status: PASS, Details: fmt.Sprintf("remote %q points at github.com/%s/%s", t.Remote, t.Owner, repoName)})
+}
+
+func checkMagicModules(path string) Result {
+	check := "magic-modules clone"
+	if path == "" {
+		return Result{Check: check, Status: PASS, Details: "magicModulesPath isn't set, so no clone is needed"}
+	}
+
+	gi := git.GitInteract{Dir: path}
+	if cmd, err := gi.IsRepository(); err != nil {
+		return Result{Check: check, Status: WARN, Details: cmd.ErrorDescription(fmt.Sprintf("%s is not a git repository", path))}
+	}
+	remotes, cmd, err := gi.GetRemotes()
+	if err != nil {
+		return Result{Check: check, Status: WARN, Details: cmd.ErrorDescription("error when listing remotes")}
+	}
+	if !pointsAtRepo(remotes, github.MAGIC_MODULES_REPO_NAME) {
+		return Result{Check: check, Status: WARN, Details: fmt.Sprintf("no remote in %s points at a %s repository, check this is the right clone", path, github.MAGIC_MODULES_REPO_NAME)}
+	}
+	return Result{Check: check, Status: PASS, Details: fmt.Sprintf("%s is a clone of %s", path, github.MAGIC_MODULES_REPO_NAME)}
+}
+
+func checkTemplates(target *config.Target, magicModulesPath string) Result {
+	check := fmt.Sprintf("%s changelog templates", target.Name)
+	t, err := changelog.ResolveTargetTemplates(target, magicModulesPath)
+	if err != nil {
+		return Result{Check: check, Status: FAIL, Details: err.Error()}
+	}
+	defer t.Cleanup()
+
+	if len(t.Warnings) > 0 {
+		return Result{Check: check, Status: WARN, Details: strings.Join(t.Warnings, "\n")}
+	}
+	return Result{Check: check, Status: PASS, Details: fmt.Sprintf("templates found at %s and %s", t.ChangelogPath, t.ReleaseNotePath)}
+}
+
+func checkToken(ctx context.Context, gh UserFinder, source string, tokenErr error) Result {
+	check := "GitHub token"
+	if tokenErr != nil {
+		return Result{Check: check, Status: FAIL, Details: tokenErr.Error()}
+	}
+	if gh == nil {
+		return Result{Check: check, Status: FAIL, Details: "no GitHub token found"}
+	}
+
+	user, scopes, err := gh.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return Result{Check: check, Status: FAIL, Details: err.Error()}
+	}
+	if len(scopes) > 0 {
+		return Result{Check: check, Status: WARN, Details: fmt.Sprintf("token from %s authenticates as %s but has scopes %s, a token with no permissions is all that's needed", source, user.Login, strings.Join(scopes, ", "))}
+	}
+	return Result{Check: check, Status: PASS, Details: fmt.Sprintf("token from %s authenticates as %s", source, user.Login)}
+}
+
+func checkChangelogGen(executable string) Result {
+	check := "changelog-gen"
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return Result{Check: check, Status: FAIL, Details: fmt.Sprintf("%s isn't in your PATH, download it via: go install github.com/paultyng/changelog-gen@master", executable)}
+	}
+
+	// changelog-gen has no version flag, so read the version of the module it was built from
+	out, err := exec.Command("go", "version", "-m", path).Output()
+	if err != nil {
+		return Result{Check: check, Status: WARN, Details: fmt.Sprintf("found at %s, but unable to determine its version: %s", path, err)}
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return Result{Check: check, Status: PASS, Details: fmt.Sprintf("found at %s, version %s of %s", path, fields[2], fields[1])}
+		}
+	}
+	return Result{Check: check, Status: WARN, Details: fmt.Sprintf("found at %s, but unable to determine its version", path)}
+}
+
+func checkGit() Result {
+	check := "git"
+	version, cmd, err := git.Version()
+	if err != nil {
+		return Result{Check: check, Status: FAIL, Details: cmd.ErrorDescription("unable to run git")}
+	}
+	return Result{Check: check, Status: PASS, Details: version}
+}
+
+func pointsAtRepo(remotes map[string]string, repoName string) bool {
+	for _, url := range remotes {
+		if _, repo, ok := setup.ParseGitHubRemote(url); ok && strings.EqualFold(repo, repoName) {
+			return true
+		}
+	}
+	return false
+}
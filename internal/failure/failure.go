@@ -0,0 +1,140 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/remediation"
+)
+
+// ExitCode is the exit code of the CLI for a class of failure, so that wrapper scripts and pipelines can decide what
+// to do next. The values are documented in the README and must not change.
+type ExitCode int
+
+const (
+	EXIT_OK ExitCode = 0
+	// EXIT_UNKNOWN is for errors that don't belong to any other class
+	EXIT_UNKNOWN ExitCode = 1
+	// EXIT_USAGE is for invalid flags and arguments, and is also used by the flag package
+	EXIT_USAGE     ExitCode = 2
+	EXIT_CONFIG    ExitCode = 3
+	EXIT_INPUT     ExitCode = 4
+	EXIT_GIT       ExitCode = 5
+	EXIT_GIT_PUSH  ExitCode = 6
+	EXIT_GITHUB    ExitCode = 7
+	EXIT_CHANGELOG ExitCode = 8
+	EXIT_CANCELLED ExitCode = 9
+)
+
+// ErrCancelled is returned when the user stops the release, e.g. by declining to confirm it
+var ErrCancelled = errors.New("cancelled by the user")
+
+// ConfigError is a problem with the config file or the environment the CLI runs in, e.g. changelog-gen missing from
+// PATH or a GitHub token that can't be read. Nothing has been changed yet.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string { return e.Err.Error() }
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// InputError is an invalid or missing input, e.g. a malformed release version or too many invalid answers to a
+// prompt. Nothing has been changed yet, so the release can be retried with different inputs.
+type InputError struct {
+	Err error
+}
+
+func (e *InputError) Error() string { return e.Err.Error() }
+func (e *InputError) Unwrap() error { return e.Err }
+
+// GitError is a git command that failed
+type GitError struct {
+	// Summary describes what the command was for, e.g. "error when checking out main"
+	Summary string
+	Command git.GitCommand
+	// Push is true if the command pushed to the remote, so the remote may have been partly updated
+	Push bool
+}
+
+func (e *GitError) Error() string { return e.Command.ErrorDescription(e.Summary) }
+func (e *GitError) Unwrap() error { return e.Command.Err() }
+
+// PushMismatchError is a release branch that was pushed without an error, but that doesn't point at the release commit
+// on the remote afterwards, e.g. because the remote's push URL is a fork
+type PushMismatchError struct {
+	Branch string
+	Remote string
+	// Expected is the release commit
+	Expected string
+	// Actual is the commit the branch points at on the remote, or empty if the branch isn't on the remote
+	Actual string
+}
+
+func (e *PushMismatchError) Error() string {
+	if e.Actual == "" {
+		return fmt.Sprintf("%s was pushed, but it isn't on the %s remote. Check where the push went with `git remote -v`, e.g. a push URL that's a fork.", e.Branch, e.Remote)
+	}
+	return fmt.Sprintf("%s was pushed, but it points at %s on the %s remote instead of the release commit %s. Check the branch on the remote before releasing from it.", e.Branch, e.Actual, e.Remote, e.Expected)
+}
+
+// GitHubError is a request to the GitHub API that failed
+type GitHubError struct {
+	Err error
+}
+
+func (e *GitHubError) Error() string { return withHint(e.Err.Error()) }
+func (e *GitHubError) Unwrap() error { return e.Err }
+
+// ChangelogError is a failure to generate or edit the changelog. The release branch has already been pushed.
+// changelog-gen makes requests to the GitHub API, so the error can include a hint about GitHub failures.
+type ChangelogError struct {
+	Err error
+}
+
+func (e *ChangelogError) Error() string { return withHint(e.Err.Error()) }
+func (e *ChangelogError) Unwrap() error { return e.Err }
+
+// withHint adds advice on how to fix the failure to an error message, if it's a common failure. Git errors already
+// include hints, see git.GitCommand.ErrorDescription.
+func withHint(message string) string {
+	if r := remediation.Find(message); r != nil {
+		return fmt.Sprintf("%s\n%s", message, r)
+	}
+	return message
+}
+
+// Code returns the exit code for an error. An error can wrap errors of several classes, e.g. an input that couldn't be
+// collected because of a failed GitHub request, so the class that's most specific about the cause is used: cancelling
+// comes first, then git, GitHub and changelog failures, then config and input errors.
+func Code(err error) ExitCode {
+	var gitErr *GitError
+	var pushMismatchErr *PushMismatchError
+	var githubErr *GitHubError
+	var changelogErr *ChangelogError
+	var configErr *ConfigError
+	var inputErr *InputError
+
+	switch {
+	case err == nil:
+		return EXIT_OK
+	case errors.Is(err, ErrCancelled):
+		return EXIT_CANCELLED
+	case errors.As(err, &gitErr):
+		if gitErr.Push {
+			return EXIT_GIT_PUSH
+		}
+		return EXIT_GIT
+	case errors.As(err, &pushMismatchErr):
+		return EXIT_GIT_PUSH
+	case errors.As(err, &githubErr):
+		return EXIT_GITHUB
+	case errors.As(err, &changelogErr):
+		return EXIT_CHANGELOG
+	case errors.As(err, &configErr):
+		return EXIT_CONFIG
+	case errors.As(err, &inputErr):
+		return EXIT_INPUT
+	}
+	return EXIT_UNKNOWN
+}
@@ -0,0 +1,109 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+)
+
+func Test_Code(t *testing.T) {
+	// A git command that fails because the directory isn't a git repository
+	gi := git.GitInteract{Dir: t.TempDir()}
+	_, cmd, err := gi.GetMergeBase("v6.5.0")
+	if err == nil {
+		t.Fatal("expected git command to fail")
+	}
+
+	cases := map[string]struct {
+		err          error
+		expectedCode ExitCode
+	}{
+		"no error": {
+			err:          nil,
+			expectedCode: EXIT_OK,
+		},
+		"unclassified error": {
+			err:          errors.New("something went wrong"),
+			expectedCode: EXIT_UNKNOWN,
+		},
+		"config error": {
+			err:          &ConfigError{Err: errors.New("invalid config file")},
+			expectedCode: EXIT_CONFIG,
+		},
+		"input error": {
+			err:          &InputError{Err: errors.New("invalid release version")},
+			expectedCode: EXIT_INPUT,
+		},
+		"git error": {
+			err:          &GitError{Summary: "error when getting last release's commit", Command: cmd},
+			expectedCode: EXIT_GIT,
+		},
+		"git push error": {
+			err:          &GitError{Summary: "error when pushing the new release branch", Command: cmd, Push: true},
+			expectedCode: EXIT_GIT_PUSH,
+		},
+		"pushed branch doesn't match": {
+			err:          &PushMismatchError{Branch: "release-6.6.0", Remote: "upstream", Expected: "33db873052ab34b92b5f6512bd874730a0f83164"},
+			expectedCode: EXIT_GIT_PUSH,
+		},
+		"GitHub error": {
+			err:          &GitHubError{Err: errors.New("404 Not Found")},
+			expectedCode: EXIT_GITHUB,
+		},
+		"changelog error": {
+			err:          &ChangelogError{Err: errors.New("error when running changelog-gen")},
+			expectedCode: EXIT_CHANGELOG,
+		},
+		"cancelled": {
+			err:          fmt.Errorf("%w, release stopped", ErrCancelled),
+			expectedCode: EXIT_CANCELLED,
+		},
+		"input error caused by a GitHub error": {
+			err:          &InputError{Err: fmt.Errorf("error collecting user inputs: %w", &GitHubError{Err: errors.New("404 Not Found")})},
+			expectedCode: EXIT_GITHUB,
+		},
+		"input error caused by cancelling": {
+			err:          &InputError{Err: fmt.Errorf("error collecting user inputs: %w", ErrCancelled)},
+			expectedCode: EXIT_CANCELLED,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			if code := Code(tc.err); code != tc.expectedCode {
+				t.Fatalf("wanted exit code %d, got %d", tc.expectedCode, code)
+			}
+		})
+	}
+}
+
+func Test_GitError(t *testing.T) {
+	gi := git.GitInteract{Dir: t.TempDir()}
+	_, cmd, runErr := gi.GetMergeBase("v6.5.0")
+	if runErr == nil {
+		t.Fatal("expected git command to fail")
+	}
+
+	err := &GitError{Summary: "error when getting last release's commit", Command: cmd}
+	if err.Error() != cmd.ErrorDescription("error when getting last release's commit") {
+		t.Fatalf("expected the error to describe the command, got %q", err.Error())
+	}
+	if !errors.Is(err, cmd.Err()) {
+		t.Fatal("expected the error to wrap the error from running the command")
+	}
+}
+
+func Test_GitHubError_hint(t *testing.T) {
+	err := &GitHubError{Err: errors.New(`got a non-200 response: status '403 Forbidden', body '{"message":"API rate limit exceeded for 192.0.2.1."}'`)}
+	if !strings.Contains(err.Error(), "Hint: GitHub's API rate limit was reached.") {
+		t.Fatalf("expected the error to include a hint, got %q", err.Error())
+	}
+
+	err = &GitHubError{Err: errors.New("got a non-200 response: status '404 Not Found'")}
+	if strings.Contains(err.Error(), "Hint:") {
+		t.Fatalf("expected no hint for an unrecognised failure, got %q", err.Error())
+	}
+}
@@ -2,16 +2,72 @@ package git
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/remediation"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
 )
 
 // GitInteract contains all the user-supplied information for interacting with git while preparing the releas
 type GitInteract struct {
-	Dir             string
+	Dir string
+	// PreviousRelease is the tag of the latest release
 	PreviousRelease string
 	Remote          string
+	// TrunkBranch is the branch that releases are cut from, e.g. main
+	TrunkBranch string
+
+	// Context stops running commands when it's cancelled, e.g. when the user presses Ctrl-C. If nil, commands are only
+	// stopped by the timeout.
+	Context context.Context
+	// Timeout is how long each command can run for before it's stopped, or zero for no limit
+	Timeout time.Duration
+	// Progress receives the progress of commands that can take a long time, e.g. pulling tags. If nil, progress isn't shown.
+	Progress io.Writer
+	// Retry is how commands that use the network are retried when they fail because of a network problem. The zero
+	// value means no retries.
+	Retry retry.Policy
+}
+
+// Commit describes a commit in the provider repository
+type Commit struct {
+	Sha     string
+	Message string
+
+	// Author and Date are only set for commits returned by GetRecentCommits
+	Author string
+	// Date is when the commit was committed, i.e. when it was merged into the trunk branch
+	Date time.Time
+}
+
+// Subject returns the first line of the commit message
+func (c Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+// upstreamTrailerRE matches the trailer that the Modular Magician adds to downstream commits
+// to record the magic-modules commit they were generated from, e.g. [upstream:33db873052ab34b92b5f6512bd874730a0f83164]
+var upstreamTrailerRE = regexp.MustCompile(`\[upstream:([0-9a-f]{7,40})\]`)
+
+// UpstreamSha returns the SHA of the magic-modules commit that this commit was generated from,
+// or an empty string if the commit message has no upstream trailer.
+func (c Commit) UpstreamSha() string {
+	matches := upstreamTrailerRE.FindStringSubmatch(c.Message)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
 }
 
 // GitCommand describes a git command that has been executed
@@ -20,6 +76,111 @@ type GitCommand struct {
 	stdout *bytes.Buffer
 	stderr *bytes.Buffer
 	runErr error
+
+	// interact is the settings the command was run with, so that related commands can be run in the same way
+	interact GitInteract
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// STOP_GRACE_PERIOD is how long git has to exit after it's interrupted, before it's killed
+var STOP_GRACE_PERIOD = 10 * time.Second
+
+// newCommand prepares a git command that runs in the repository. The command is stopped if the context is cancelled
+// or the timeout is reached.
+func (c *GitInteract) newCommand(args ...string) GitCommand {
+	ctx := c.context()
+	gc := GitCommand{
+		stdout:   &bytes.Buffer{},
+		stderr:   &bytes.Buffer{},
+		interact: *c,
+	}
+	if c.Timeout > 0 {
+		gc.ctx, gc.cancel = context.WithTimeout(ctx, c.Timeout)
+	} else {
+		gc.ctx, gc.cancel = context.WithCancel(ctx)
+	}
+
+	cmd := exec.CommandContext(gc.ctx, "git", args...)
+	cmd.Dir = c.Dir
+	cmd.Stderr = gc.stderr
+	cmd.Stdout = gc.stdout
+	// Interrupt git rather than killing it, so that it removes any lock files and leaves the repository usable
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = STOP_GRACE_PERIOD
+	gc.cmd = cmd
+	return gc
+}
+
+// newProgressCommand prepares a git command that can take a long time on large repositories, e.g. pulling tags, with
+// its progress written to the Progress writer
+func (c *GitInteract) newProgressCommand(subcommand string, args ...string) GitCommand {
+	if c.Progress == nil {
+		return c.newCommand(append([]string{subcommand}, args...)...)
+	}
+	gc := c.newCommand(append([]string{subcommand, "--progress"}, args...)...)
+	gc.cmd.Stderr = io.MultiWriter(gc.stderr, c.Progress)
+	return gc
+}
+
+func (c *GitInteract) context() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+// runWithRetries runs a command that uses the network, running it again if it fails because of a network problem.
+// newCmd is called for each attempt, because a command can only be run once. operation describes the command when
+// telling the user about a retry, e.g. "Pulling tags".
+func (c *GitInteract) runWithRetries(operation string, newCmd func() GitCommand) (GitCommand, error) {
+	var gc GitCommand
+	err := c.Retry.Do(c.context(),
+		func() error {
+			gc = newCmd()
+			return gc.run()
+		},
+		func(error) bool {
+			return gc.isTransient()
+		},
+		func(err error, wait time.Duration, attempt int) {
+			if c.Progress != nil {
+				fmt.Fprintln(c.Progress, c.Retry.Message(operation, wait, attempt))
+			}
+		},
+	)
+	return gc, err
+}
+
+// isTransient returns whether the command failed because of a network problem, e.g. a dropped connection, rather than
+// a problem that will happen again, e.g. a branch that already exists. A command that was stopped by the timeout isn't
+// retried, because each attempt gets the whole timeout and a hung connection would hold up the release for several
+// times as long.
+func (gc *GitCommand) isTransient() bool {
+	if gc.runErr == nil || errors.Is(gc.runErr, context.Canceled) || errors.Is(gc.runErr, context.DeadlineExceeded) {
+		return false
+	}
+	return retry.IsTransientOutput(gc.stderr.String())
+}
+
+// run runs the command and records any error. If the command was stopped, the error says why.
+func (gc *GitCommand) run() error {
+	defer gc.cancel()
+
+	err := gc.cmd.Run()
+	if err == nil {
+		return nil
+	}
+	switch ctxErr := gc.ctx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		err = fmt.Errorf("stopped after the %s timeout for git commands (%w): %w", gc.interact.Timeout, ctxErr, err)
+	case ctxErr != nil:
+		err = fmt.Errorf("stopped because it was cancelled (%w): %w", ctxErr, err)
+	}
+	gc.runErr = err
+	return err
 }
 
 func NewGitInteract(directory, previousRelease string) *GitInteract {
@@ -30,19 +191,16 @@ func NewGitInteract(directory, previousRelease string) *GitInteract {
 }
 
 func (c *GitInteract) GetLastReleaseCommit() (string, GitCommand, error) {
-
 	// Get the common commit between the last release and the new release we're preparing
-	gc := GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
-	}
-	gc.cmd = exec.Command("git", "merge-base", "main", c.PreviousRelease)
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
+	return c.GetMergeBase(c.PreviousRelease)
+}
+
+// GetMergeBase returns the common commit between the remote-tracking trunk branch, e.g. upstream/main, and the supplied
+// ref, e.g. a release tag. The local trunk branch isn't used because it can be behind or have diverged from the remote.
+func (c *GitInteract) GetMergeBase(ref string) (string, GitCommand, error) {
+	gc := c.newCommand("merge-base", c.RemoteTrunkBranch(), ref)
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
+	if err := gc.run(); err != nil {
 		return "", gc, err
 	}
 
@@ -50,115 +208,221 @@ func (c *GitInteract) GetLastReleaseCommit() (string, GitCommand, error) {
 	return commit, gc, nil
 }
 
-func (c *GitInteract) PullTagsMainBranch() (GitCommand, error) {
-	gc := GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
+// GetCommitsInRange returns the commits reachable from `to` but not from `from`, newest first
+func (c *GitInteract) GetCommitsInRange(from, to string) ([]Commit, GitCommand, error) {
+	// Each commit is output as <SHA>NUL<message>RS so messages containing newlines can be split reliably
+	gc := c.newCommand("log", "--format=%H%x00%B%x1e", fmt.Sprintf("%s..%s", from, to))
+
+	if err := gc.run(); err != nil {
+		return nil, gc, err
 	}
-	gc.cmd = exec.Command("git", "pull", c.Remote, "--tags")
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
-		return gc, err
+	var commits []Commit
+	for _, record := range strings.Split(gc.stdout.String(), "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		sha, message, _ := strings.Cut(record, "\x00")
+		commits = append(commits, Commit{
+			Sha:     sha,
+			Message: strings.TrimSpace(message),
+		})
 	}
+	return commits, gc, nil
+}
 
-	return gc, nil
+// GetRecentCommits returns the latest n commits on ref, newest first
+func (c *GitInteract) GetRecentCommits(ref string, n int) ([]Commit, GitCommand, error) {
+	// Each commit is output as <SHA>NUL<author>NUL<committer date>NUL<message>RS
+	gc := c.newCommand("log", "-n", strconv.Itoa(n), "--format=%H%x00%an%x00%cI%x00%B%x1e", ref)
+
+	if err := gc.run(); err != nil {
+		return nil, gc, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(gc.stdout.String(), "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", 4)
+		if len(fields) != 4 {
+			gc.runErr = fmt.Errorf("unexpected output from git log: %q", record)
+			return nil, gc, gc.runErr
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			gc.runErr = err
+			return nil, gc, err
+		}
+		commits = append(commits, Commit{
+			Sha:     fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Message: strings.TrimSpace(fields[3]),
+		})
+	}
+	return commits, gc, nil
 }
 
-func (c *GitInteract) Checkout(ref string) (GitCommand, error) {
-	gc := GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
+// IsAncestor returns whether the commit is reachable from ref, e.g. whether a commit has been merged into a branch
+func (c *GitInteract) IsAncestor(commit, ref string) (bool, GitCommand, error) {
+	gc := c.newCommand("merge-base", "--is-ancestor", commit, ref)
+
+	err := gc.run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// Exit code 1 means the commit isn't an ancestor, other errors mean the check couldn't be made
+		gc.runErr = nil
+		return false, gc, nil
+	}
+	if err != nil {
+		return false, gc, err
+	}
+	return true, gc, nil
+}
+
+// FetchTrunkBranch updates the remote-tracking branch of the trunk branch, e.g. upstream/main, without changing any local branches
+func (c *GitInteract) FetchTrunkBranch() (GitCommand, error) {
+	return c.runWithRetries(fmt.Sprintf("Fetching %s", c.RemoteTrunkBranch()), func() GitCommand {
+		return c.newProgressCommand("fetch", c.Remote, c.TrunkBranch)
+	})
+}
+
+// FetchTagsTrunkBranch is FetchTrunkBranch that also fetches the remote's tags, so that release tags can be resolved.
+// Unlike `git pull`, nothing is merged into the branch that's checked out.
+func (c *GitInteract) FetchTagsTrunkBranch() (GitCommand, error) {
+	return c.runWithRetries(fmt.Sprintf("Fetching %s and tags", c.RemoteTrunkBranch()), func() GitCommand {
+		return c.newProgressCommand("fetch", c.Remote, c.TrunkBranch, "--tags")
+	})
+}
+
+// RemoteTrunkBranch returns the name of the remote-tracking branch of the trunk branch, e.g. upstream/main
+func (c *GitInteract) RemoteTrunkBranch() string {
+	return fmt.Sprintf("%s/%s", c.Remote, c.TrunkBranch)
+}
+
+// Version returns the version of git that is installed, e.g. "git version 2.39.5"
+func Version() (string, GitCommand, error) {
+	gc := (&GitInteract{}).newCommand("--version")
+
+	if err := gc.run(); err != nil {
+		return "", gc, err
 	}
-	gc.cmd = exec.Command("git", "checkout", ref)
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
+	return strings.TrimSpace(gc.stdout.String()), gc, nil
+}
+
+// IsRepository returns an error if the directory isn't inside a git repository
+func (c *GitInteract) IsRepository() (GitCommand, error) {
+	gc := c.newCommand("rev-parse", "--git-dir")
+
+	if err := gc.run(); err != nil {
 		return gc, err
 	}
 
 	return gc, nil
 }
 
-func (c *GitInteract) GetLastCommitOfCurrentRelease(branchName string) (string, GitCommand, error) {
-	gc := GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
+// GetRemotes returns the fetch URL of each remote in the repository, keyed by remote name
+func (c *GitInteract) GetRemotes() (map[string]string, GitCommand, error) {
+	gc := c.newCommand("remote", "-v")
+
+	if err := gc.run(); err != nil {
+		return nil, gc, err
 	}
 
-	// Checkout commit
-	gc.cmd = exec.Command("git", "checkout", branchName)
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
+	// Lines are in the format: <name>\t<url> (fetch|push)
+	remotes := map[string]string{}
+	for _, line := range strings.Split(gc.stdout.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			remotes[fields[0]] = fields[1]
+		}
+	}
+	return remotes, gc, nil
+}
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
+// ShowFile returns the contents of a file at the supplied ref, without checking out that ref
+func (c *GitInteract) ShowFile(ref, path string) (string, GitCommand, error) {
+	gc := c.newCommand("show", fmt.Sprintf("%s:%s", ref, path))
+
+	if err := gc.run(); err != nil {
 		return "", gc, err
 	}
 
-	// Get last commit
-	gc = GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
-	}
+	return gc.stdout.String(), gc, nil
+}
 
-	gc.cmd = exec.Command("git", "rev-list", "-n", "1", "HEAD")
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
+func (c *GitInteract) Checkout(ref string) (GitCommand, error) {
+	gc := c.newCommand("checkout", ref)
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
-		return "", gc, err
+	if err := gc.run(); err != nil {
+		return gc, err
 	}
 
-	lastCommit := strings.ReplaceAll(gc.stdout.String(), "\n", "")
-	return lastCommit, gc, nil
+	return gc, nil
 }
 
-func (c *GitInteract) CreateAndPushReleaseBranch(releaseVersion string) (string, GitCommand, error) {
-	gc := GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
+// GetCheckedOut returns the branch that's checked out, or the commit that's checked out if HEAD is detached, so that it
+// can be checked out again later
+func (c *GitInteract) GetCheckedOut() (string, GitCommand, error) {
+	gc := c.newCommand("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err := gc.run(); err == nil {
+		return strings.TrimSpace(gc.stdout.String()), gc, nil
 	}
+	return c.GetCommit("HEAD")
+}
 
-	version := strings.TrimPrefix(releaseVersion, "v") // Remove prefix v1.2.3 => 1.2.3
-	branchName := fmt.Sprintf("release-%s", version)
+// GetCommit returns the full SHA of the commit that a ref, e.g. a short SHA or a branch, points at
+func (c *GitInteract) GetCommit(ref string) (string, GitCommand, error) {
+	gc := c.newCommand("rev-parse", "--verify", ref+"^{commit}")
 
-	// Create branch locally
-	gc.cmd = exec.Command("git", "checkout", "-b", branchName)
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
+	if err := gc.run(); err != nil {
+		return "", gc, err
+	}
+
+	return strings.TrimSpace(gc.stdout.String()), gc, nil
+}
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
+// GetRemoteBranchCommit asks the remote which commit a branch points at, without relying on the local clone's
+// remote-tracking branches. The commit is empty if the branch doesn't exist on the remote.
+func (c *GitInteract) GetRemoteBranchCommit(branchName string) (string, GitCommand, error) {
+	gc, err := c.runWithRetries(fmt.Sprintf("Checking %s on the %s remote", branchName, c.Remote), func() GitCommand {
+		return c.newCommand("ls-remote", "--heads", c.Remote, "refs/heads/"+branchName)
+	})
+	if err != nil {
 		return "", gc, err
 	}
 
-	// Push branch
-	gc = GitCommand{
-		stdout: &bytes.Buffer{},
-		stderr: &bytes.Buffer{},
+	// Lines are in the format: <sha>\t<ref>
+	for _, line := range strings.Split(gc.stdout.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "refs/heads/"+branchName {
+			return fields[0], gc, nil
+		}
 	}
-	gc.cmd = exec.Command("git", "push", "-u", c.Remote, branchName)
-	gc.cmd.Dir = c.Dir
-	gc.cmd.Stderr = gc.stderr
-	gc.cmd.Stdout = gc.stdout
+	return "", gc, nil
+}
 
-	if err := gc.cmd.Run(); err != nil {
-		gc.runErr = err
-		return "", gc, err
+// CreateReleaseBranch creates the release branch from the commit that's checked out, and checks it out
+func (c *GitInteract) CreateReleaseBranch(branchName string) (GitCommand, error) {
+	gc := c.newCommand("checkout", "-b", branchName)
+
+	if err := gc.run(); err != nil {
+		return gc, err
 	}
 
-	return branchName, gc, nil
+	return gc, nil
+}
+
+// PushReleaseBranch pushes the release branch to the remote and sets it as the upstream branch
+func (c *GitInteract) PushReleaseBranch(branchName string) (GitCommand, error) {
+	return c.runWithRetries(fmt.Sprintf("Pushing %s", branchName), func() GitCommand {
+		return c.newProgressCommand("push", "-u", c.Remote, branchName)
+	})
 }
 
 // ErrorDescription returns a formatted string describing how a CLI command has failed
@@ -168,6 +432,37 @@ func (c *GitInteract) CreateAndPushReleaseBranch(releaseVersion string) (string,
 //   - the directory
 //   - the error returned from (c *exec.Cmd).Run()
 //   - the stderr from the command
+//   - a hint on how to fix the failure, if it's a common one
+//
+// Secrets, such as tokens in remote URLs, are redacted from the output
 func (gc *GitCommand) ErrorDescription(summary string) string {
-	return fmt.Sprintf("%s:\n\tCommand: `%s`\n\tDirectory: %s\n\tError: %s\n\tStdErr: %s", summary, gc.cmd.String(), gc.cmd.Dir, gc.runErr, gc.stderr.String())
+	description := fmt.Sprintf("%s:\n\tCommand: `%s`\n\tDirectory: %s\n\tError: %s\n\tStdErr: %s", summary, gc.cmd.String(), gc.cmd.Dir, gc.runErr, gc.stderr.String())
+	if r := gc.Remediation(); r != nil {
+		description = fmt.Sprintf("%s\n\t%s", strings.TrimRight(description, "\n"), r)
+	}
+	return secrets.Redact(description)
+}
+
+// Remediation returns advice for fixing the failure, or nil if it isn't a failure that's recognised
+func (gc *GitCommand) Remediation() *remediation.Remediation {
+	if gc.runErr == nil {
+		return nil
+	}
+	return remediation.Find(gc.stderr.String())
+}
+
+// RunFix runs a remediation's fix in the same directory as the failed command
+func (gc *GitCommand) RunFix(fix remediation.Fix) (GitCommand, error) {
+	fc := gc.interact.newCommand(fix.Args...)
+
+	if err := fc.run(); err != nil {
+		return fc, err
+	}
+
+	return fc, nil
+}
+
+// Err returns the error from running the command, or nil if it succeeded
+func (gc *GitCommand) Err() error {
+	return gc.runErr
 }
@@ -0,0 +1,256 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git/gittest"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+)
+
+// newClone returns a GitInteract for a clone of the remote that hasn't fetched anything from it
+func newClone(t *testing.T, remote gittest.Remote) *GitInteract {
+	t.Helper()
+	return &GitInteract{
+		Dir:             remote.NewClone(t),
+		PreviousRelease: "v1.0.0",
+		Remote:          "upstream",
+		TrunkBranch:     "main",
+	}
+}
+
+func TestFetchTagsTrunkBranch(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	gi := newClone(t, remote)
+
+	// A local main with unrelated history, which mustn't be merged into
+	gittest.Run(t, gi.Dir, "checkout", "-q", "-b", "main")
+	gittest.Run(t, gi.Dir, "commit", "-q", "--allow-empty", "-m", "local only")
+	localMain := gittest.Run(t, gi.Dir, "rev-parse", "main")
+
+	if _, _, err := gi.GetLastReleaseCommit(); err == nil {
+		t.Fatal("expected the previous release's tag to be missing before fetching")
+	}
+
+	if cmd, err := gi.FetchTagsTrunkBranch(); err != nil {
+		t.Fatal(cmd.ErrorDescription("error when fetching"))
+	}
+
+	if got := gittest.Run(t, gi.Dir, "rev-parse", "upstream/main"); got != remote.Trunk {
+		t.Fatalf("wanted upstream/main to be fetched at %s, got %s", remote.Trunk, got)
+	}
+	if got := gittest.Run(t, gi.Dir, "rev-parse", "v1.0.0^{commit}"); got != remote.Release {
+		t.Fatalf("wanted tag v1.0.0 to be fetched at %s, got %s", remote.Release, got)
+	}
+	if got := gittest.Run(t, gi.Dir, "rev-parse", "main"); got != localMain {
+		t.Fatalf("expected local main to be unchanged at %s, got %s", localMain, got)
+	}
+	if got := gittest.Run(t, gi.Dir, "status", "--porcelain"); got != "" {
+		t.Fatalf("expected the working tree to be unchanged, got %q", got)
+	}
+}
+
+func TestGetLastReleaseCommit_usesRemoteTrunkBranch(t *testing.T) {
+	remote := gittest.NewRemote(t)
+
+	cases := map[string]struct {
+		// localMain is the commit the local main branch is created at, or empty for no local main
+		localMain string
+		// diverge adds a commit to the local main branch that isn't on the remote
+		diverge bool
+	}{
+		"no local trunk branch": {},
+		"local trunk branch behind the remote": {
+			localMain: remote.Root,
+		},
+		"local trunk branch diverged from the remote": {
+			localMain: remote.Root,
+			diverge:   true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			gi := newClone(t, remote)
+			gittest.Run(t, gi.Dir, "fetch", "-q", "upstream", "main", "--tags")
+			if tc.localMain != "" {
+				gittest.Run(t, gi.Dir, "checkout", "-q", "-b", "main", tc.localMain)
+			}
+			if tc.diverge {
+				gittest.Run(t, gi.Dir, "commit", "-q", "--allow-empty", "-m", "local only")
+			}
+
+			got, cmd, err := gi.GetLastReleaseCommit()
+			if err != nil {
+				t.Fatal(cmd.ErrorDescription("error when getting last release's commit"))
+			}
+			if got != remote.Branched {
+				t.Fatalf("wanted the merge-base of upstream/main and v1.0.0, %s, got %s", remote.Branched, got)
+			}
+		})
+	}
+}
+
+func TestGetRemoteBranchCommit(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	gi := newClone(t, remote)
+
+	cases := map[string]struct {
+		branch         string
+		expectedCommit string
+	}{
+		"branch on the remote": {
+			branch:         "release-1.0.0",
+			expectedCommit: remote.Release,
+		},
+		"trunk branch on the remote": {
+			branch:         "main",
+			expectedCommit: remote.Trunk,
+		},
+		"branch that isn't on the remote": {
+			branch:         "release-1.1.0",
+			expectedCommit: "",
+		},
+		"branch whose name starts with the name of a branch on the remote": {
+			branch:         "release-1.0",
+			expectedCommit: "",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			got, cmd, err := gi.GetRemoteBranchCommit(tc.branch)
+			if err != nil {
+				t.Fatal(cmd.ErrorDescription("error when checking the branch on the remote"))
+			}
+			if got != tc.expectedCommit {
+				t.Fatalf("wanted %q, got %q", tc.expectedCommit, got)
+			}
+		})
+	}
+}
+
+func TestGetCheckedOut(t *testing.T) {
+	remote := gittest.NewRemote(t)
+
+	cases := map[string]struct {
+		// checkout is what's checked out in the clone before the test
+		checkout string
+		expected string
+	}{
+		"branch": {
+			checkout: "release-1.0.0",
+			expected: "release-1.0.0",
+		},
+		"detached HEAD": {
+			checkout: remote.Trunk,
+			expected: remote.Trunk,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			gi := newClone(t, remote)
+			gittest.Run(t, gi.Dir, "fetch", "-q", "upstream", "main", "release-1.0.0:release-1.0.0")
+			gittest.Run(t, gi.Dir, "checkout", "-q", tc.checkout)
+
+			got, cmd, err := gi.GetCheckedOut()
+			if err != nil {
+				t.Fatal(cmd.ErrorDescription("error when getting what's checked out"))
+			}
+			if got != tc.expected {
+				t.Fatalf("wanted %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+// newHungClone returns a GitInteract for a clone whose remote never answers, like an SSH connection that's waiting for
+// a host that's gone away. Commands that use the network hang until they're stopped.
+func newHungClone(t *testing.T) *GitInteract {
+	t.Helper()
+	hang := filepath.Join(t.TempDir(), "hang")
+	// The output looks like a network problem, so only the way the command was stopped decides whether it's retried
+	script := "#!/bin/sh\necho 'ssh: connect to host example.invalid port 22: Connection timed out' >&2\nexec sleep 5\n"
+	if err := os.WriteFile(hang, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_SSH_COMMAND", hang)
+	// Without this, git probes the command with -G first and discards its output
+	t.Setenv("GIT_SSH_VARIANT", "simple")
+
+	gracePeriod := STOP_GRACE_PERIOD
+	STOP_GRACE_PERIOD = 100 * time.Millisecond
+	t.Cleanup(func() { STOP_GRACE_PERIOD = gracePeriod })
+
+	return &GitInteract{
+		Dir:             gittest.NewRepo(t, "", map[string]string{"upstream": "ssh://example.invalid/provider.git"}),
+		PreviousRelease: "v1.0.0",
+		Remote:          "upstream",
+		TrunkBranch:     "main",
+		Retry:           retry.Policy{Attempts: 4, Backoff: 10 * time.Millisecond},
+		Progress:        &bytes.Buffer{},
+	}
+}
+
+func TestFetchTagsTrunkBranch_stopsHungCommand(t *testing.T) {
+	cases := map[string]struct {
+		// stop sets up the GitInteract so that the hung command is stopped
+		stop func(gi *GitInteract)
+
+		expectedError error
+		expectedMsg   string
+	}{
+		"timeout": {
+			stop: func(gi *GitInteract) {
+				gi.Timeout = 200 * time.Millisecond
+			},
+			expectedError: context.DeadlineExceeded,
+			expectedMsg:   "stopped after the 200ms timeout for git commands",
+		},
+		"cancelled": {
+			stop: func(gi *GitInteract) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(200*time.Millisecond, cancel)
+				t.Cleanup(cancel)
+				gi.Context = ctx
+			},
+			expectedError: context.Canceled,
+			expectedMsg:   "stopped because it was cancelled",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			gi := newHungClone(t)
+			tc.stop(gi)
+
+			start := time.Now()
+			cmd, err := gi.FetchTagsTrunkBranch()
+			elapsed := time.Since(start)
+
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected the fetch to be stopped with %q, got %v", tc.expectedError, err)
+			}
+			if !strings.Contains(err.Error(), tc.expectedMsg) {
+				t.Fatalf("expected the error to contain %q, got %q", tc.expectedMsg, err)
+			}
+			if desc := cmd.ErrorDescription("error when fetching"); !strings.Contains(desc, tc.expectedMsg) {
+				t.Fatalf("expected the error description to contain %q, got %q", tc.expectedMsg, desc)
+			}
+			// Stopping git can take up to the grace period, but not the 5s the remote hangs for
+			if elapsed > 2*time.Second {
+				t.Fatalf("expected the hung command to be stopped straight away, took %s", elapsed)
+			}
+			if progress := gi.Progress.(*bytes.Buffer).String(); strings.Contains(progress, "trying again") {
+				t.Fatalf("expected the stopped command not to be retried, got:\n%s", progress)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package gittest creates git repositories for tests
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// NewRepo creates a git repository at path, or in a temporary directory if path is empty, with the supplied remotes
+// keyed by name. It returns the repository's path.
+func NewRepo(t *testing.T, path string, remotes map[string]string) string {
+	t.Helper()
+	if path == "" {
+		path = t.TempDir()
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	Run(t, path, "init", "-q")
+	for name, url := range remotes {
+		Run(t, path, "remote", "add", name, url)
+	}
+	return path
+}
+
+// Run runs git in dir and returns its trimmed stdout, failing the test if git fails
+func Run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Commits in tests don't depend on the user's git config
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		stderr := ""
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = string(exitErr.Stderr)
+		}
+		t.Fatalf("error running git %v: %s: %s", args, err, stderr)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Remote is a bare repository to use as the remote of clones in tests. Its history is:
+//
+//	Root - Branched - Trunk (main)
+//	          \
+//	           Release (release-1.0.0, tagged v1.0.0)
+type Remote struct {
+	Path string
+
+	Root, Branched, Trunk, Release string
+}
+
+// NewRemote creates a Remote in a temporary directory
+func NewRemote(t *testing.T) Remote {
+	t.Helper()
+	r := Remote{Path: t.TempDir()}
+	Run(t, r.Path, "init", "-q", "--bare")
+
+	work := NewRepo(t, "", map[string]string{"upstream": r.Path})
+	commit := func(message string) string {
+		Run(t, work, "commit", "-q", "--allow-empty", "-m", message)
+		return Run(t, work, "rev-parse", "HEAD")
+	}
+	Run(t, work, "checkout", "-q", "-b", "main")
+	r.Root = commit("root")
+	r.Branched = commit("branched")
+	Run(t, work, "checkout", "-q", "-b", "release-1.0.0")
+	r.Release = commit("release")
+	Run(t, work, "tag", "v1.0.0")
+	Run(t, work, "checkout", "-q", "main")
+	r.Trunk = commit("trunk")
+	Run(t, work, "push", "-q", "upstream", "main", "release-1.0.0", "--tags")
+	return r
+}
+
+// NewClone creates a repository with the remote added as upstream, without fetching anything from it
+func (r Remote) NewClone(t *testing.T) string {
+	t.Helper()
+	return NewRepo(t, "", map[string]string{"upstream": r.Path})
+}
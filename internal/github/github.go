@@ -0,0 +1,165 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+)
+
+var API_URL = "https://api.github.com"
+
+var MAGIC_MODULES_OWNER = "GoogleCloudPlatform"
+var MAGIC_MODULES_REPO_NAME = "magic-modules"
+
+// Client makes authenticated requests to the GitHub API
+type Client struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	// retry is how requests are retried if they fail because of a network problem or a server error
+	retry retry.Policy
+
+	// pullRequests memoises responses from PullRequestsForCommit, keyed by request URL
+	pullRequests map[string][]PullRequest
+}
+
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+type PullRequest struct {
+	Number   int    `json:"number"`
+	HTMLURL  string `json:"html_url"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	User     User   `json:"user"`
+	MergedAt string `json:"merged_at"`
+}
+
+// PullRequestFinder looks up the pull requests associated with a commit, e.g. a Client
+type PullRequestFinder interface {
+	PullRequestsForCommit(ctx context.Context, owner, repo, sha string) ([]PullRequest, error)
+}
+
+// IsBot returns true if the user is a GitHub App or other automated account
+func (u User) IsBot() bool {
+	return u.Type == "Bot"
+}
+
+func New(token string) *Client {
+	return &Client{
+		client:       &http.Client{Timeout: 10 * time.Second},
+		baseURL:      API_URL,
+		token:        token,
+		retry:        retry.DEFAULT_POLICY,
+		pullRequests: map[string][]PullRequest{},
+	}
+}
+
+// PullRequestsForCommit returns the pull requests that are associated with a commit,
+// see https://docs.github.com/en/rest/commits/commits#list-pull-requests-associated-with-a-commit
+func (c *Client) PullRequestsForCommit(ctx context.Context, owner, repo, sha string) ([]PullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s/pulls", c.baseURL, owner, repo, sha)
+
+	// return result from previous run, if present
+	if prs, ok := c.pullRequests[url]; ok {
+		return prs, nil
+	}
+
+	prs := []PullRequest{}
+	if _, err := c.get(ctx, url, &prs); err != nil {
+		return nil, fmt.Errorf("error getting pull requests for commit %s in github.com/%s/%s : %w", sha, owner, repo, err)
+	}
+
+	// memo
+	c.pullRequests[url] = prs
+
+	return prs, nil
+}
+
+// MergedPullRequest returns the first merged pull request in a list of pull requests, e.g. from PullRequestsForCommit
+func MergedPullRequest(prs []PullRequest) (PullRequest, bool) {
+	for _, pr := range prs {
+		if pr.MergedAt != "" {
+			return pr, true
+		}
+	}
+	return PullRequest{}, false
+}
+
+// GetAuthenticatedUser returns the user that the token belongs to, and the OAuth scopes granted to the token.
+// Scopes are only reported for classic personal access tokens.
+// See https://docs.github.com/en/rest/users/users#get-the-authenticated-user
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (User, []string, error) {
+	user := User{}
+	header, err := c.get(ctx, fmt.Sprintf("%s/user", c.baseURL), &user)
+	if err != nil {
+		return User{}, nil, fmt.Errorf("error getting the authenticated user : %w", err)
+	}
+
+	var scopes []string
+	for _, s := range strings.Split(header.Get("X-OAuth-Scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return user, scopes, nil
+}
+
+// get decodes the JSON response from url into v, retrying network problems and server errors. The request and any
+// retries stop if ctx is cancelled, e.g. when the user presses Ctrl-C.
+func (c *Client) get(ctx context.Context, url string, v any) (http.Header, error) {
+	var header http.Header
+	err := c.retry.Do(ctx,
+		func() error {
+			var err error
+			header, err = c.getOnce(ctx, url, v)
+			return err
+		},
+		retry.IsTransient,
+		func(err error, wait time.Duration, attempt int) {
+			log.Printf("%s: %s", c.retry.Message("Making a request to GitHub", wait, attempt), err)
+		},
+	)
+	return header, err
+}
+
+func (c *Client) getOnce(ctx context.Context, url string, v any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		data, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("got a non-200 response: status '%s', body '%s'", resp.Status, data)
+		// Server errors are usually temporary, unlike a missing commit or a rate limit
+		if resp.StatusCode >= 500 {
+			return nil, &retry.TransientError{Err: err}
+		}
+		return nil, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("error parsing response body : %w", err)
+	}
+	return resp.Header, nil
+}
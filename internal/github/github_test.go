@@ -0,0 +1,109 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+)
+
+func newTestClient(server *httptest.Server, policy retry.Policy) *Client {
+	return &Client{
+		client:       server.Client(),
+		baseURL:      server.URL,
+		retry:        policy,
+		pullRequests: map[string][]PullRequest{},
+	}
+}
+
+func TestClient_PullRequestsForCommit_retries(t *testing.T) {
+	cases := map[string]struct {
+		statuses         []int
+		expectedRequests int
+		expectError      bool
+	}{
+		"retries server errors": {
+			statuses:         []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			expectedRequests: 3,
+		},
+		"gives up after all attempts": {
+			statuses:         []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			expectedRequests: 3,
+			expectError:      true,
+		},
+		"doesn't retry a missing commit": {
+			statuses:         []int{http.StatusUnprocessableEntity, http.StatusOK},
+			expectedRequests: 1,
+			expectError:      true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statuses[requests])
+				requests++
+				w.Write([]byte(`[{"number": 123, "merged_at": "2024-09-23T10:00:00Z"}]`))
+			}))
+			defer server.Close()
+
+			c := newTestClient(server, retry.Policy{Attempts: 3, Backoff: time.Millisecond})
+			prs, err := c.PullRequestsForCommit(context.Background(), "hashicorp", "terraform-provider-google", "abc123")
+			if requests != tc.expectedRequests {
+				t.Fatalf("wanted %d requests, got %d", tc.expectedRequests, requests)
+			}
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if len(prs) != 1 || prs[0].Number != 123 {
+				t.Fatalf("wanted PR 123, got %v", prs)
+			}
+		})
+	}
+}
+
+func TestClient_PullRequestsForCommit_cancelled(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// Cancelled while waiting to retry, which would otherwise take a minute
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newTestClient(server, retry.Policy{Attempts: 3, Backoff: time.Minute})
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := c.PullRequestsForCommit(ctx, "hashicorp", "terraform-provider-google", "abc123")
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the retries to stop when cancelled, took %s", elapsed)
+	}
+	if requests != 1 {
+		t.Fatalf("wanted 1 request, got %d", requests)
+	}
+
+	// Nothing is requested once cancelled
+	if _, err := c.PullRequestsForCommit(ctx, "hashicorp", "terraform-provider-google", "def456"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the request to be cancelled, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("wanted no more requests after cancelling, got %d", requests-1)
+	}
+}
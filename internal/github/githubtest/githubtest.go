@@ -0,0 +1,22 @@
+// Package githubtest provides a fake GitHub client for tests of code that looks up pull requests
+package githubtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+)
+
+// FakeFinder is a github.PullRequestFinder that returns PRs keyed by owner/repo/sha
+type FakeFinder map[string][]github.PullRequest
+
+func (f FakeFinder) PullRequestsForCommit(ctx context.Context, owner, repo, sha string) ([]github.PullRequest, error) {
+	return f[fmt.Sprintf("%s/%s/%s", owner, repo, sha)], nil
+}
+
+// MergedPR returns pr marked as merged, in the list that PullRequestsForCommit returns
+func MergedPR(pr github.PullRequest) []github.PullRequest {
+	pr.MergedAt = "2024-09-23T10:00:00Z"
+	return []github.PullRequest{pr}
+}
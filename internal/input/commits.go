@@ -0,0 +1,81 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+)
+
+// DEFAULT_COMMIT_PICKER_SIZE is the number of recent commits on the trunk branch that the user can pick from
+var DEFAULT_COMMIT_PICKER_SIZE = 15
+
+// MIN_SHA_LENGTH is the shortest abbreviated SHA that's accepted when picking a commit
+var MIN_SHA_LENGTH = 7
+
+// MAX_SUBJECT_LENGTH is the length that commit subjects are shortened to when listing commits
+var MAX_SUBJECT_LENGTH = 60
+
+// CommitFinder finds commits on the trunk branch of a target's repository, so the user can pick one to cut the release from
+type CommitFinder interface {
+	// RecentCommits returns the latest n commits on the trunk branch, newest first
+	RecentCommits(t *config.Target, n int) ([]git.Commit, error)
+	// IsOnTrunk returns whether the commit has been merged into the trunk branch. A commit that isn't in the clone at
+	// all, e.g. one from another repository, isn't on the trunk branch, so it isn't an error.
+	IsOnTrunk(t *config.Target, sha string) (bool, error)
+}
+
+// defaultCommitIndex returns the index of the newest commit made at or before the cutoff, or -1 if none of the
+// commits are that old. If there's no cutoff the newest commit is the default.
+func defaultCommitIndex(commits []git.Commit, cutoff time.Time) int {
+	if len(commits) == 0 {
+		return -1
+	}
+	if cutoff.IsZero() {
+		return 0
+	}
+	for i, c := range commits {
+		if !c.Date.After(cutoff) {
+			return i
+		}
+	}
+	return -1
+}
+
+// commitOptions returns the commits as the numbered rows of a table for printing to the terminal, with the default
+// commit marked by *. Choosing a row gives the number of the commit as the answer.
+func commitOptions(commits []git.Commit, defaultIndex int) (string, []Option) {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSHA\tDATE\tAUTHOR\tSUBJECT\tUPSTREAM")
+	for i, c := range commits {
+		number := fmt.Sprintf("%d", i+1)
+		if i == defaultIndex {
+			number += "*"
+		}
+		upstream := c.UpstreamSha()
+		if len(upstream) > 7 {
+			upstream = upstream[:7]
+		}
+		if upstream == "" {
+			upstream = "-"
+		}
+		subject := []rune(c.Subject())
+		if len(subject) > MAX_SUBJECT_LENGTH {
+			subject = append(subject[:MAX_SUBJECT_LENGTH-3], []rune("...")...)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", number, c.Sha[:7], c.Date.Local().Format("2006-01-02 15:04"), c.Author, string(subject), upstream)
+	}
+	w.Flush()
+
+	rows := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	var options []Option
+	for i, row := range rows[1:] {
+		options = append(options, Option{Label: row, Answer: fmt.Sprintf("%d", i+1)})
+	}
+	return rows[0], options
+}
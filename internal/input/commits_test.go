@@ -0,0 +1,166 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+)
+
+// testCommits are recent commits on the trunk branch, newest first
+var testCommits = []git.Commit{
+	{Sha: "c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3", Author: "Modular Magician", Date: time.Date(2024, 10, 15, 9, 0, 0, 0, time.UTC), Message: "Add field to resource\n\n[upstream:1234567890abcdef1234567890abcdef12345678]"},
+	{Sha: "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2", Author: "Modular Magician", Date: time.Date(2024, 10, 14, 16, 0, 0, 0, time.UTC), Message: "Fix bug"},
+	{Sha: "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1", Author: "Modular Magician", Date: time.Date(2024, 10, 11, 12, 0, 0, 0, time.UTC), Message: "Update docs"},
+}
+
+// fakeCommitFinder returns testCommits, and treats commits starting d4d4d4d as the only other commits on the trunk branch
+type fakeCommitFinder struct {
+	err error
+}
+
+func (f fakeCommitFinder) RecentCommits(t *config.Target, n int) ([]git.Commit, error) {
+	return testCommits, f.err
+}
+
+func (f fakeCommitFinder) IsOnTrunk(t *config.Target, sha string) (bool, error) {
+	return strings.HasPrefix(sha, "d4d4d4d"), nil
+}
+
+func Test_defaultCommitIndex(t *testing.T) {
+	cases := map[string]struct {
+		cutoff   time.Time
+		expected int
+	}{
+		"no cutoff picks the latest commit": {
+			expected: 0,
+		},
+		"cutoff picks the last commit before it": {
+			cutoff:   time.Date(2024, 10, 14, 17, 0, 0, 0, time.UTC),
+			expected: 1,
+		},
+		"commit made exactly at the cutoff": {
+			cutoff:   time.Date(2024, 10, 11, 12, 0, 0, 0, time.UTC),
+			expected: 2,
+		},
+		"no commits before the cutoff": {
+			cutoff:   time.Date(2024, 10, 7, 17, 0, 0, 0, time.UTC),
+			expected: -1,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			if got := defaultCommitIndex(testCommits, tc.cutoff); got != tc.expected {
+				t.Fatalf("wanted %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func Test_Handler_PromptAndProcessCommitPickerInput(t *testing.T) {
+	cases := map[string]struct {
+		userInput      string
+		defaultIndex   int
+		expectedCommit string
+		expectError    bool
+	}{
+		"accepting the default": {
+			userInput:      "\n",
+			defaultIndex:   1,
+			expectedCommit: testCommits[1].Sha,
+		},
+		"picking by number": {
+			userInput:      "3\n",
+			defaultIndex:   1,
+			expectedCommit: testCommits[2].Sha,
+		},
+		"picking a listed commit by short SHA": {
+			userInput:      "C3C3C3C\n",
+			expectedCommit: testCommits[0].Sha,
+		},
+		"entering a SHA on the trunk branch that isn't listed": {
+			userInput:      "d4d4d4d4d4\n",
+			expectedCommit: "d4d4d4d4d4",
+		},
+		"entering a SHA that isn't on the trunk branch, then a number": {
+			userInput:      "e5e5e5e5e5\n2\n",
+			expectedCommit: testCommits[1].Sha,
+		},
+		"no default": {
+			userInput:    "\n\n\n",
+			defaultIndex: -1,
+			expectError:  true,
+		},
+		"numbers out of range": {
+			userInput:   "0\n4\nabc\n",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{Target: &testTargets[0]}
+			handler := NewHandler(&input, testTargets)
+			handler.reader = bufio.NewReader(strings.NewReader(tc.userInput))
+
+			err := handler.PromptAndProcessCommitPickerInput(testCommits, tc.defaultIndex, "", func(sha string) (bool, error) {
+				return fakeCommitFinder{}.IsOnTrunk(input.Target, sha)
+			})
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if input.CommitSha != tc.expectedCommit {
+				t.Fatalf("wanted commit %q, got %q", tc.expectedCommit, input.CommitSha)
+			}
+		})
+	}
+}
+
+func Test_Resolver_Resolve_commitPicker(t *testing.T) {
+	cases := map[string]struct {
+		finder         fakeCommitFinder
+		cutoff         time.Time
+		userInput      string
+		expectedCommit string
+	}{
+		"default is the last commit before the cutoff": {
+			cutoff:         time.Date(2024, 10, 14, 17, 0, 0, 0, time.UTC),
+			userInput:      "\n",
+			expectedCommit: testCommits[1].Sha,
+		},
+		"listing commits fails, so the user enters a SHA": {
+			finder:         fakeCommitFinder{err: errors.New("no network")},
+			userInput:      "f6f6f6f\n",
+			expectedCommit: "f6f6f6f",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+			handler.reader = bufio.NewReader(strings.NewReader(tc.userInput))
+
+			r := Resolver{
+				Flags:        Values{Target: "ga", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+				Handler:      &handler,
+				Commits:      tc.finder,
+				CommitCutoff: tc.cutoff,
+			}
+			if err := r.Resolve(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if input.CommitSha != tc.expectedCommit {
+				t.Fatalf("wanted commit %q, got %q", tc.expectedCommit, input.CommitSha)
+			}
+		})
+	}
+}
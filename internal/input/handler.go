@@ -4,28 +4,79 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"golang.org/x/mod/semver"
 )
 
+// DEFAULT_MAX_ATTEMPTS is the number of invalid answers to a prompt that are allowed before giving up
+var DEFAULT_MAX_ATTEMPTS = 3
+
+// HELP_ANSWER can be entered at any prompt to show help for the question
+var HELP_ANSWER = "?"
+
 type Handler struct {
 	reader *bufio.Reader
+	// waiting is true while the handler is waiting for an answer on stdin
+	waiting *atomic.Bool
+	// prompter asks questions instead of the command line, if set
+	prompter Prompter
 
 	input *Input
+	// targets are the release targets the user can choose from
+	targets []config.Target
+	// maxAttempts is the number of invalid answers allowed for each prompt
+	maxAttempts int
 }
 
-func NewHandler(input *Input) Handler {
+func NewHandler(input *Input, targets []config.Target) Handler {
 
 	reader := bufio.NewReader(os.Stdin)
 
 	return Handler{
-		reader: reader,
-		input:  input,
+		reader:      reader,
+		waiting:     &atomic.Bool{},
+		input:       input,
+		targets:     targets,
+		maxAttempts: DEFAULT_MAX_ATTEMPTS,
+	}
+}
+
+// SetMaxAttempts sets the number of invalid answers allowed for each prompt before giving up
+func (h *Handler) SetMaxAttempts(n int) {
+	if n < 1 {
+		n = 1
+	}
+	h.maxAttempts = n
+}
+
+// SetPrompter makes the handler ask questions using the prompter, e.g. a terminal UI, instead of the command line
+func (h *Handler) SetPrompter(p Prompter) {
+	h.prompter = p
+}
+
+// Notify shows the user a message, on the command line or using the prompter
+func (h *Handler) Notify(message string) {
+	if h.prompter != nil {
+		h.prompter.Notify(message)
+		return
 	}
+	fmt.Println(message)
+}
+
+// SetReader makes the handler read answers from r instead of stdin
+func (h *Handler) SetReader(r io.Reader) {
+	h.reader = bufio.NewReader(r)
 }
 
 func (h *Handler) WaitForResponse() (string, error) {
-	pv, err := h.reader.ReadString('\n')
+	pv, err := h.ReadLine()
 	if err != nil {
 		return "", err
 	}
@@ -33,74 +84,254 @@ func (h *Handler) WaitForResponse() (string, error) {
 	return pv, nil
 }
 
-func (h *Handler) PromptAndProcessProviderChoiceInput() error {
+// ReadLine waits for a line on stdin and returns it as it was entered, e.g. for answers like paths that are case
+// sensitive, unlike WaitForResponse
+func (h *Handler) ReadLine() (string, error) {
+	h.waiting.Store(true)
+	line, err := h.reader.ReadString('\n')
+	h.waiting.Store(false)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
 
-	fmt.Println("What provider do you want to make a release for (ga/beta)?")
+// Waiting returns whether the handler is waiting for an answer on stdin. Reading stdin can't be cancelled, so when the
+// user presses Ctrl-C at a prompt there's nothing to wait for before exiting.
+func (h *Handler) Waiting() bool {
+	return h.waiting.Load()
+}
 
-	pv, err := h.WaitForResponse()
-	if err != nil {
-		return err
+// ask asks a question and passes the answer to process, asking again if process returns an error until
+// the answer is valid or the user has run out of attempts. Answering ? shows help for the question.
+func (h *Handler) ask(p Prompt, process func(answer string) error) error {
+	attempts := 0
+	for {
+		answer, err := h.prompt(p)
+		if err != nil {
+			return err
+		}
+		if answer == HELP_ANSWER {
+			h.Notify(p.Help)
+			p.Invalid = nil
+			continue
+		}
+
+		err = process(answer)
+		if err == nil {
+			return nil
+		}
+		attempts++
+		if attempts >= h.maxAttempts {
+			return fmt.Errorf("%w (gave up after %d invalid answers)", err, attempts)
+		}
+		p.Invalid = err
 	}
-	if err := h.input.SetProvider(pv); err != nil {
-		return err
+}
+
+// prompt asks a question once, using the prompter if there is one
+func (h *Handler) prompt(p Prompt) (string, error) {
+	if h.prompter != nil {
+		answer, err := h.prompter.Ask(p)
+		return prepareStdinInput(answer), err
+	}
+
+	if p.Invalid != nil {
+		fmt.Printf("Invalid answer: %s. Try again, or enter %s for help.\n", p.Invalid, HELP_ANSWER)
+	}
+	if p.Header != "" {
+		fmt.Println(p.Header)
+		for _, o := range p.Options {
+			fmt.Println(o.Label)
+		}
+		fmt.Println()
+	}
+	fmt.Println(p.Question)
+	return h.WaitForResponse()
+}
+
+// askYesNo asks a yes/no question until y or n is answered
+func (h *Handler) askYesNo(question, help string) (bool, error) {
+	var yes bool
+	p := Prompt{
+		Question: fmt.Sprintf("%s (y/n)", question),
+		Help:     help,
+		Options:  []Option{{Label: "Yes", Answer: "y"}, {Label: "No", Answer: "n"}},
+		Default:  -1,
+	}
+	err := h.ask(p, func(answer string) error {
+		switch answer {
+		case "y":
+			yes = true
+			return nil
+		case "n":
+			yes = false
+			return nil
+		}
+		return errors.New("bad input where y/n was expected")
+	})
+	return yes, err
+}
+
+func (h *Handler) PromptAndProcessProviderChoiceInput() error {
+
+	var names []string
+	for _, t := range h.targets {
+		names = append(names, t.Name)
+	}
+	question := fmt.Sprintf("What provider do you want to make a release for (%s)?", strings.Join(names, "/"))
+
+	var help strings.Builder
+	help.WriteString("Enter the name of the release target to make a release for. The targets in your config are:\n")
+	var options []Option
+	for _, t := range h.targets {
+		help.WriteString(fmt.Sprintf("\t%s: %s\n", t.Name, t.Repo))
+		options = append(options, Option{Label: fmt.Sprintf("%s (%s)", t.Name, t.Repo), Answer: t.Name})
 	}
-	return nil
+
+	p := Prompt{Question: question, Help: help.String(), Options: options, Default: -1}
+	return h.ask(p, func(answer string) error {
+		return h.input.SetTarget(answer, h.targets)
+	})
 }
 
 func (h *Handler) PromptAndProcessReleaseVersionChoiceInput(lastReleaseVersion, possibleNextVersion string) error {
 
-	fmt.Printf("The latest release of %s is %s\n", h.input.GetProviderRepoName(), lastReleaseVersion)
-	fmt.Printf("Are you planning on making the next minor release, %s? (y/n)\n", possibleNextVersion)
+	h.Notify(fmt.Sprintf("The latest release of %s is %s", h.input.GetProviderRepoName(), lastReleaseVersion))
 
-	in, err := h.WaitForResponse()
+	question := fmt.Sprintf("Are you planning on making the next minor release, %s?", possibleNextVersion)
+	help := fmt.Sprintf("Answer y to release %s, the next minor version after the latest release. Answer n to enter the versions yourself, e.g. for a patch release, major release or backport.", possibleNextVersion)
+	yes, err := h.askYesNo(question, help)
 	if err != nil {
 		return err
 	}
+	if yes {
+		return h.input.SetReleaseVersions(possibleNextVersion, lastReleaseVersion)
+	}
 
-	switch in {
-	case "y":
-		if err := h.input.SetReleaseVersions(possibleNextVersion, lastReleaseVersion); err != nil {
-			return err
+	// The user might be making a patch release, major release, or a backport. Asking for previous version and new version enables all these.
+	var old string
+	p := Prompt{
+		Question: "Provide the previous release version as a semver string, e.g. v1.2.3:",
+		Help:     h.versionHelp("the most recent release before the one you're preparing"),
+		Default:  -1,
+	}
+	err = h.ask(p, func(answer string) error {
+		if !semver.IsValid(answer) {
+			return fmt.Errorf("%q is not a semver string starting with v, e.g. v1.2.3", answer)
 		}
+		old = answer
 		return nil
-	case "n":
-		// The user might be making a patch release, major release, or a backport. Asking for previous version and new version enables all these.
-		fmt.Println("Provide the previous release version as a semver string, e.g. v1.2.3:")
+	})
+	if err != nil {
+		return err
+	}
 
-		old, err := h.reader.ReadString('\n')
-		if err != nil {
-			return err
-		}
-		old = prepareStdinInput(old)
+	return h.PromptAndProcessNewReleaseVersionInput(old)
+}
 
-		fmt.Println("Provide the new release version we are prepating as a semver string, e.g. v1.2.3:")
-		new, err := h.reader.ReadString('\n')
-		if err != nil {
-			return err
-		}
-		new = prepareStdinInput(new)
+// PromptAndProcessPreviousReleaseVersionInput asks for the previous release version when only the new version is known
+func (h *Handler) PromptAndProcessPreviousReleaseVersionInput(newReleaseVersion string) error {
 
-		if err := h.input.SetReleaseVersions(new, old); err != nil {
-			return err
-		}
-		return nil
+	p := Prompt{
+		Question: fmt.Sprintf("Provide the release version that came before %s as a semver string, e.g. v1.2.3:", newReleaseVersion),
+		Help:     h.versionHelp(fmt.Sprintf("the most recent release before %s", newReleaseVersion)),
+		Default:  -1,
 	}
-	return errors.New("bad input where y/n was expected, exiting")
+	return h.ask(p, func(answer string) error {
+		return h.input.SetReleaseVersions(newReleaseVersion, answer)
+	})
+}
+
+// PromptAndProcessNewReleaseVersionInput asks for the new release version when only the previous version is known
+func (h *Handler) PromptAndProcessNewReleaseVersionInput(previousReleaseVersion string) error {
+
+	p := Prompt{
+		Question: fmt.Sprintf("Provide the new release version that comes after %s as a semver string, e.g. v1.2.3:", previousReleaseVersion),
+		Help:     h.versionHelp(fmt.Sprintf("the version you're preparing, which must be later than %s", previousReleaseVersion)),
+		Default:  -1,
+	}
+	return h.ask(p, func(answer string) error {
+		return h.input.SetReleaseVersions(answer, previousReleaseVersion)
+	})
 }
 
 func (h *Handler) PromptAndProcessCommitChoiceInput() error {
 
-	fmt.Println("What commit do you want to use to cut the release?")
+	p := Prompt{
+		Question: "What commit do you want to use to cut the release?",
+		Help:     "Enter the SHA of the commit on the main branch to cut the release from, e.g. build.vcs.number from the TeamCity build that's being released.",
+		Default:  -1,
+	}
+	return h.ask(p, func(answer string) error {
+		return h.input.SetCommit(answer)
+	})
+}
 
-	c, err := h.WaitForResponse()
-	if err != nil {
-		return err
+// PromptAndProcessCommitPickerInput lists recent commits on the trunk branch and asks the user to pick one by number.
+// Entering nothing picks the default commit, if there is one. A SHA can be entered instead, e.g. from TeamCity, as long
+// as isOnTrunk confirms that the commit is on the trunk branch of the target's repository. note is shown beneath the
+// list, e.g. to say why the default commit was chosen, and can be empty.
+func (h *Handler) PromptAndProcessCommitPickerInput(commits []git.Commit, defaultIndex int, note string, isOnTrunk func(sha string) (bool, error)) error {
+
+	t := h.input.Target
+	h.Notify(fmt.Sprintf("Recent commits on the %s branch of %s:\n", t.TrunkBranch, t.Repo))
+
+	question := fmt.Sprintf("What commit do you want to use to cut the release? Enter a number from 1 to %d", len(commits))
+	if defaultIndex >= 0 {
+		question += fmt.Sprintf(", or nothing to use %d*", defaultIndex+1)
+	}
+	question += ":"
+	if note != "" {
+		question = note + "\n" + question
 	}
+	help := fmt.Sprintf("Enter the number of a commit in the list above. Commits marked with an upstream SHA were generated from that magic-modules commit. You can also enter the SHA of another commit on the %s branch of %s, e.g. build.vcs.number from the TeamCity build that's being released.", t.TrunkBranch, t.Repo)
 
-	if err := h.input.SetCommit(c); err != nil {
-		return err
+	header, options := commitOptions(commits, defaultIndex)
+	p := Prompt{Question: question, Help: help, Header: header, Options: options, Default: defaultIndex}
+	return h.ask(p, func(answer string) error {
+		if answer == "" {
+			if defaultIndex < 0 {
+				return errors.New("there is no default commit, enter a number or a SHA")
+			}
+			return h.input.SetCommit(commits[defaultIndex].Sha)
+		}
+
+		// Short answers are numbers from the list, longer answers are SHAs
+		if len(answer) < MIN_SHA_LENGTH {
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < 1 || n > len(commits) {
+				return fmt.Errorf("%q isn't a number from 1 to %d, or a SHA of at least %d characters", answer, len(commits), MIN_SHA_LENGTH)
+			}
+			return h.input.SetCommit(commits[n-1].Sha)
+		}
+		for _, c := range commits {
+			if strings.HasPrefix(c.Sha, answer) {
+				return h.input.SetCommit(c.Sha)
+			}
+		}
+		onTrunk, err := isOnTrunk(answer)
+		if err != nil {
+			return fmt.Errorf("unable to find commit %s in %s: %w", answer, t.Repo, err)
+		}
+		if !onTrunk {
+			return fmt.Errorf("commit %s isn't on the %s branch of %s or wasn't found in the clone, check it's from the right repository", answer, t.TrunkBranch, t.Repo)
+		}
+		return h.input.SetCommit(answer)
+	})
+}
+
+// Confirm asks the user a yes/no question and returns whether they answered yes
+func (h *Handler) Confirm(question string) (bool, error) {
+	return h.askYesNo(question, "Answer y for yes, or n for no.")
+}
+
+func (h *Handler) versionHelp(description string) string {
+	help := fmt.Sprintf("Enter %s, as a semver string starting with v, e.g. v1.2.3.", description)
+	if t := h.input.Target; t != nil {
+		help += fmt.Sprintf(" Previous releases are listed at https://github.com/%s/%s/releases", t.Owner, t.Repo)
 	}
-	return nil
+	return help
 }
 
 func prepareStdinInput(in string) string {
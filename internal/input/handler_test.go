@@ -3,31 +3,46 @@ package input
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"io"
 	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
 )
 
+// testTargets are the targets created from config without a list of targets, plus an extra target
+var testTargets = []config.Target{
+	{Name: "ga", Repo: "terraform-provider-google"},
+	{Name: "beta", Repo: "terraform-provider-google-beta"},
+	{Name: "tgc", Repo: "terraform-google-conversion"},
+}
+
 func Test_Handler_PromptAndProcessProviderChoiceInput(t *testing.T) {
 
 	cases := map[string]struct {
-		userInput              string
-		expectedChosenProvider Provider
-		expectError            bool
+		userInput            string
+		expectedChosenTarget string
+		expectError          bool
 	}{
 		"choosing GA provider: ga": {
-			userInput:              "ga\n",
-			expectedChosenProvider: GA,
+			userInput:            "ga\n",
+			expectedChosenTarget: "ga",
 		},
 		"choosing GA provider: GA": {
-			userInput:              "GA\n",
-			expectedChosenProvider: GA,
+			userInput:            "GA\n",
+			expectedChosenTarget: "ga",
 		},
 		"choosing Beta provider: beta": {
-			userInput:              "beta\n",
-			expectedChosenProvider: BETA,
+			userInput:            "beta\n",
+			expectedChosenTarget: "beta",
 		},
 		"choosing Beta provider: BETA": {
-			userInput:              "BETA\n",
-			expectedChosenProvider: BETA,
+			userInput:            "BETA\n",
+			expectedChosenTarget: "beta",
+		},
+		"choosing another configured target: tgc": {
+			userInput:            "tgc\n",
+			expectedChosenTarget: "tgc",
 		},
 		"responding incorrectly": {
 			userInput:   "foobar\n",
@@ -41,7 +56,7 @@ func Test_Handler_PromptAndProcessProviderChoiceInput(t *testing.T) {
 			stdin.Write([]byte(tc.userInput))
 
 			input := Input{}
-			handler := NewHandler(&input)
+			handler := NewHandler(&input, testTargets)
 
 			r := bufio.NewReader(&stdin)
 			handler.reader = r
@@ -54,8 +69,12 @@ func Test_Handler_PromptAndProcessProviderChoiceInput(t *testing.T) {
 				t.Fatal("expected error but got none")
 			}
 
-			if input.Provider != tc.expectedChosenProvider {
-				t.Fatalf("wanted %v, got %v", providerToString[tc.expectedChosenProvider], providerToString[input.Provider])
+			chosen := ""
+			if input.Target != nil {
+				chosen = input.Target.Name
+			}
+			if chosen != tc.expectedChosenTarget {
+				t.Fatalf("wanted %q, got %q", tc.expectedChosenTarget, chosen)
 			}
 		})
 	}
@@ -85,7 +104,7 @@ func Test_Handler_PromptAndProcessCommitChoiceInput(t *testing.T) {
 			stdin.Write([]byte(tc.userInput))
 
 			input := Input{}
-			handler := NewHandler(&input)
+			handler := NewHandler(&input, testTargets)
 
 			r := bufio.NewReader(&stdin)
 			handler.reader = r
@@ -155,7 +174,7 @@ func Test_Handler_PromptAndProcessReleaseVersionChoiceInput(t *testing.T) {
 			stdin.Write([]byte(userInput))
 
 			input := Input{}
-			handler := NewHandler(&input)
+			handler := NewHandler(&input, testTargets)
 
 			r := bufio.NewReader(&stdin)
 			handler.reader = r
@@ -177,3 +196,196 @@ func Test_Handler_PromptAndProcessReleaseVersionChoiceInput(t *testing.T) {
 		})
 	}
 }
+
+func Test_Handler_Confirm(t *testing.T) {
+
+	cases := map[string]struct {
+		userInput   string
+		expected    bool
+		expectError bool
+	}{
+		"answering y": {
+			userInput: "y\n",
+			expected:  true,
+		},
+		"answering Y": {
+			userInput: "Y\n",
+			expected:  true,
+		},
+		"answering n": {
+			userInput: "n\n",
+			expected:  false,
+		},
+		"responding incorrectly": {
+			userInput:   "foobar\n",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			stdin := bytes.Buffer{}
+			stdin.Write([]byte(tc.userInput))
+
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+
+			r := bufio.NewReader(&stdin)
+			handler.reader = r
+
+			got, err := handler.Confirm("Do you want to continue?")
+			if err != nil && !tc.expectError {
+				t.Fatal(err.Error())
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+
+			if got != tc.expected {
+				t.Fatalf("wanted %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func Test_Handler_repromptsOnInvalidAnswers(t *testing.T) {
+
+	commit := "33db873052ab34b92b5f6512bd874730a0f83164"
+
+	cases := map[string]struct {
+		userInput   string
+		maxAttempts int
+		prompt      func(h *Handler) error
+		check       func(i Input) bool
+		expectError bool
+	}{
+		"typo in provider, then valid answer": {
+			userInput: "gaa\nga\n",
+			prompt:    func(h *Handler) error { return h.PromptAndProcessProviderChoiceInput() },
+			check:     func(i Input) bool { return i.Target != nil && i.Target.Name == "ga" },
+		},
+		"help, then valid answer": {
+			userInput: "?\nbeta\n",
+			prompt:    func(h *Handler) error { return h.PromptAndProcessProviderChoiceInput() },
+			check:     func(i Input) bool { return i.Target != nil && i.Target.Name == "beta" },
+		},
+		"help doesn't use up attempts": {
+			userInput:   "?\n?\n?\n" + commit + "\n",
+			maxAttempts: 1,
+			prompt:      func(h *Handler) error { return h.PromptAndProcessCommitChoiceInput() },
+			check:       func(i Input) bool { return i.CommitSha == commit },
+		},
+		"empty commit, then valid answer": {
+			userInput: "\n" + commit + "\n",
+			prompt:    func(h *Handler) error { return h.PromptAndProcessCommitChoiceInput() },
+			check:     func(i Input) bool { return i.CommitSha == commit },
+		},
+		"bad y/n and malformed versions, then valid answers": {
+			userInput: "yes\nn\n9.9.0\nv9.9.0\nv9.8.0\nv9.10.0\n",
+			prompt:    func(h *Handler) error { return h.PromptAndProcessReleaseVersionChoiceInput("v3.1.4", "v3.2.0") },
+			check:     func(i Input) bool { return i.PreviousReleaseVersion == "v9.9.0" && i.ReleaseVersion == "v9.10.0" },
+		},
+		"giving up after the default number of attempts": {
+			userInput:   "alpha\nbravo\ncharlie\nga\n",
+			prompt:      func(h *Handler) error { return h.PromptAndProcessProviderChoiceInput() },
+			check:       func(i Input) bool { return i.Target == nil },
+			expectError: true,
+		},
+		"giving up after a configured number of attempts": {
+			userInput:   "alpha\nga\n",
+			maxAttempts: 1,
+			prompt:      func(h *Handler) error { return h.PromptAndProcessProviderChoiceInput() },
+			check:       func(i Input) bool { return i.Target == nil },
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+			handler.reader = bufio.NewReader(bytes.NewBufferString(tc.userInput))
+			if tc.maxAttempts != 0 {
+				handler.SetMaxAttempts(tc.maxAttempts)
+			}
+
+			err := tc.prompt(&handler)
+			if err != nil && !tc.expectError {
+				t.Fatal(err.Error())
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if !tc.check(input) {
+				t.Fatalf("input has unexpected values: %#v", input)
+			}
+		})
+	}
+}
+
+// fakePrompter answers prompts in order and records what was asked
+type fakePrompter struct {
+	answers  []string
+	prompts  []Prompt
+	messages []string
+}
+
+func (f *fakePrompter) Ask(p Prompt) (string, error) {
+	f.prompts = append(f.prompts, p)
+	if len(f.answers) == 0 {
+		return "", io.EOF
+	}
+	answer := f.answers[0]
+	f.answers = f.answers[1:]
+	return answer, nil
+}
+
+func (f *fakePrompter) Notify(message string) {
+	f.messages = append(f.messages, message)
+}
+
+func Test_Handler_SetPrompter(t *testing.T) {
+	input := Input{}
+	handler := NewHandler(&input, testTargets)
+	prompter := &fakePrompter{answers: []string{"alpha", HELP_ANSWER, "tgc"}}
+	handler.SetPrompter(prompter)
+
+	if err := handler.PromptAndProcessProviderChoiceInput(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if input.Target == nil || input.Target.Name != "tgc" {
+		t.Fatalf("expected target tgc to be chosen, got %#v", input.Target)
+	}
+
+	if len(prompter.prompts) != 3 {
+		t.Fatalf("expected 3 prompts, got %d", len(prompter.prompts))
+	}
+	if len(prompter.prompts[0].Options) != len(testTargets) {
+		t.Fatalf("expected an option for each target, got %#v", prompter.prompts[0].Options)
+	}
+	if prompter.prompts[0].Invalid != nil || prompter.prompts[1].Invalid == nil {
+		t.Fatal("expected the prompt after an invalid answer to say why it was invalid")
+	}
+	if prompter.prompts[2].Invalid != nil {
+		t.Fatal("expected the prompt after asking for help not to repeat the invalid answer")
+	}
+	if len(prompter.messages) != 1 || prompter.messages[0] != prompter.prompts[0].Help {
+		t.Fatalf("expected help to be shown once, got messages %q", prompter.messages)
+	}
+}
+
+func Test_NonInteractive(t *testing.T) {
+	input := Input{}
+	handler := NewHandler(&input, testTargets)
+	var output bytes.Buffer
+	handler.SetPrompter(NonInteractive{Output: &output})
+
+	if _, err := handler.Confirm("Do you want to continue?"); !errors.Is(err, ErrNonInteractive) {
+		t.Fatalf("expected ErrNonInteractive, got %v", err)
+	}
+
+	handler.Notify("The latest release of terraform-provider-google is v6.5.0")
+	if output.String() != "The latest release of terraform-provider-google is v6.5.0\n" {
+		t.Fatalf("unexpected output: %q", output.String())
+	}
+}
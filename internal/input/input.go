@@ -2,26 +2,10 @@ package input
 
 import (
 	"errors"
-	"fmt"
-)
-
-type Provider int
 
-const (
-	UNSET Provider = iota
-	GA
-	BETA
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
 )
 
-var providerToString = map[Provider]string{
-	UNSET: "UNSET",
-	GA:    "GA",
-	BETA:  "BETA",
-}
-
-var GA_REPO_NAME = "terraform-provider-google"
-var BETA_REPO_NAME = "terraform-provider-google-beta"
-
 type Input struct {
 	// CommitSha is the SHA1 hash of the commit we want to use as the basis of the new release
 	CommitSha string
@@ -29,8 +13,8 @@ type Input struct {
 	ReleaseVersion string
 	// PreviousReleaseVersion is the latest release's semver tag in format v1.2.3
 	PreviousReleaseVersion string
-	// Provider records whether we're creating a relase for the GA or Beta version of the provider
-	Provider Provider
+	// Target is the configured release target we're creating a release for, e.g. the GA or Beta version of the provider
+	Target *config.Target
 }
 
 func (i *Input) Validate() error {
@@ -43,7 +27,7 @@ func (i *Input) Validate() error {
 	if err := validateVersionInputs(i.ReleaseVersion, i.PreviousReleaseVersion); err != nil {
 		errs = append(errs, err)
 	}
-	if !(i.Provider == GA || i.Provider == BETA) {
+	if i.Target == nil {
 		errs = append(errs, errors.New("provider is not set"))
 	}
 
@@ -64,36 +48,26 @@ func (i *Input) SetCommit(commit string) error {
 	return nil
 }
 
-func (i *Input) SetProvider(providerVersion string) error {
-	err := validateProviderInputs(providerVersion)
+// SetTarget sets the release target using its name, e.g. a provider choice of "ga" or "beta"
+func (i *Input) SetTarget(name string, targets []config.Target) error {
+	t, err := validateTargetInput(name, targets)
 	if err != nil {
 		return err
 	}
 
-	switch providerVersion {
-	case "ga":
-		i.Provider = GA
-	case "beta":
-		i.Provider = BETA
-	default:
-		return fmt.Errorf("unexpected provider version value: %s, expected 'ga' or 'beta'", providerVersion)
-	}
+	i.Target = t
 	return nil
 }
 
-func (i *Input) SetProviderFromFlags(ga, beta bool) error {
-	err := validateGaBetaInputs(ga, beta)
+// SetTargetFromFlags sets the release target using the -target flag, or the -ga and -beta flags
+// which are shorthands for the targets called ga and beta
+func (i *Input) SetTargetFromFlags(target string, ga, beta bool, targets []config.Target) error {
+	name, err := validateTargetFlags(target, ga, beta)
 	if err != nil {
 		return err
 	}
 
-	switch {
-	case ga:
-		i.Provider = GA
-	case beta:
-		i.Provider = BETA
-	}
-	return nil
+	return i.SetTarget(name, targets)
 }
 
 func (i *Input) SetReleaseVersions(new, old string) error {
@@ -110,51 +84,8 @@ func (i *Input) SetReleaseVersions(new, old string) error {
 }
 
 func (i *Input) GetProviderRepoName() string {
-	switch i.Provider {
-	case GA:
-		return GA_REPO_NAME
-	case BETA:
-		return BETA_REPO_NAME
-	case UNSET:
+	if i.Target == nil {
 		return "provider has not been set"
-	default:
-		return ""
 	}
-}
-
-// New returns an instance of Input
-// This function is intended for use with flag inputs only
-func New(ga, beta bool, commitSha, releaseVersion, previousReleaseVersion string) (Input, error) {
-
-	errs := compositeValidationError{}
-
-	if err := validateGaBetaInputs(ga, beta); err != nil {
-		errs = append(errs, err)
-	}
-	if err := validateCommitShaInput(commitSha); err != nil {
-		errs = append(errs, err)
-	}
-	if err := validateVersionInputs(releaseVersion, previousReleaseVersion); err != nil {
-		errs = append(errs, err)
-	}
-
-	if len(errs) > 0 {
-		return Input{}, errs
-	}
-
-	i := Input{
-		CommitSha:              commitSha,
-		ReleaseVersion:         releaseVersion,
-		PreviousReleaseVersion: previousReleaseVersion,
-	}
-	switch {
-	case ga:
-		i.Provider = GA
-	case beta:
-		i.Provider = BETA
-	default:
-		return Input{}, fmt.Errorf("unable to determing provider version value from inputs")
-	}
-
-	return i, nil
+	return i.Target.Repo
 }
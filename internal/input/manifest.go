@@ -0,0 +1,116 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"golang.org/x/mod/semver"
+)
+
+// Manifest describes the parameters of a release in a file, so parameters that were reviewed before
+// the release can drive the CLI. Values in a release override those at the top level, e.g. when the
+// GA and Beta providers are released with the same versions but from different commits.
+type Manifest struct {
+	ReleaseVersion         string `json:"releaseVersion,omitempty"`
+	PreviousReleaseVersion string `json:"previousReleaseVersion,omitempty"`
+	// Edit opens the generated changelog in $EDITOR, like the -edit flag
+	Edit bool `json:"edit,omitempty"`
+
+	Releases []ManifestRelease `json:"releases"`
+}
+
+// ManifestRelease describes the release of a single target
+type ManifestRelease struct {
+	Target                 string `json:"target"`
+	CommitSha              string `json:"commitSha,omitempty"`
+	ReleaseVersion         string `json:"releaseVersion,omitempty"`
+	PreviousReleaseVersion string `json:"previousReleaseVersion,omitempty"`
+}
+
+// LoadManifest reads a manifest from a JSON, YAML or TOML file and validates its values.
+// Values can be left out of the manifest, to be supplied in another way.
+func LoadManifest(path string, targets []config.Target) (*Manifest, error) {
+	m := &Manifest{}
+	if err := config.DecodeFile(path, m); err != nil {
+		return nil, err
+	}
+
+	// Apply top-level values to each release
+	for i := range m.Releases {
+		if m.Releases[i].ReleaseVersion == "" {
+			m.Releases[i].ReleaseVersion = m.ReleaseVersion
+		}
+		if m.Releases[i].PreviousReleaseVersion == "" {
+			m.Releases[i].PreviousReleaseVersion = m.PreviousReleaseVersion
+		}
+	}
+
+	if err := m.validate(targets); err != nil {
+		return nil, fmt.Errorf("error in manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *Manifest) validate(targets []config.Target) error {
+	var errs compositeValidationError
+
+	if len(m.Releases) == 0 {
+		errs = append(errs, errors.New("there are no releases, add at least one release with a target"))
+	}
+
+	seen := map[string]bool{}
+	for i, r := range m.Releases {
+		if r.Target == "" {
+			errs = append(errs, fmt.Errorf("releases[%d] has no target", i))
+			continue
+		}
+		t, err := validateTargetInput(r.Target, targets)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("releases[%d] has target %q: %w", i, r.Target, err))
+			continue
+		}
+		if seen[t.Name] {
+			errs = append(errs, fmt.Errorf("there is more than one release for target %q", t.Name))
+		}
+		seen[t.Name] = true
+
+		// Versions are validated together when both are present, as they would be when supplied by flags
+		switch {
+		case r.ReleaseVersion != "" && r.PreviousReleaseVersion != "":
+			if err := validateVersionInputs(r.ReleaseVersion, r.PreviousReleaseVersion); err != nil {
+				errs = append(errs, fmt.Errorf("release for target %q: %w", t.Name, err))
+			}
+		case r.ReleaseVersion != "" && !semver.IsValid(r.ReleaseVersion):
+			errs = append(errs, fmt.Errorf("release for target %q: releaseVersion is not in correct format", t.Name))
+		case r.PreviousReleaseVersion != "" && !semver.IsValid(r.PreviousReleaseVersion):
+			errs = append(errs, fmt.Errorf("release for target %q: previousReleaseVersion is not in correct format", t.Name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Release returns the release in the manifest for the named target. If name is empty, the manifest
+// must contain a single release.
+func (m *Manifest) Release(name string) (*ManifestRelease, error) {
+	var names []string
+	for i := range m.Releases {
+		if name != "" && strings.EqualFold(m.Releases[i].Target, name) {
+			return &m.Releases[i], nil
+		}
+		names = append(names, m.Releases[i].Target)
+	}
+
+	if name == "" && len(m.Releases) == 1 {
+		return &m.Releases[0], nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the manifest contains releases for %s, choose one with the -target flag", strings.Join(names, ", "))
+	}
+	return nil, fmt.Errorf("the manifest doesn't contain a release for %q, it contains releases for %s", name, strings.Join(names, ", "))
+}
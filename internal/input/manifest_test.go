@@ -0,0 +1,114 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeManifest(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("error writing manifest: %s", err)
+	}
+	return path
+}
+
+func Test_LoadManifest(t *testing.T) {
+	cases := map[string]struct {
+		fileName    string
+		contents    string
+		expectError bool
+	}{
+		"YAML manifest for GA and Beta": {
+			fileName: "release.yaml",
+			contents: `releaseVersion: v6.6.0
+previousReleaseVersion: v6.5.0
+edit: true
+releases:
+  - target: ga
+    commitSha: 33db873052ab34b92b5f6512bd874730a0f83164
+  - target: beta
+    commitSha: 6f1d6c1b4a2f4e0c8d1b3a5e7f9c0d2e4a6b8c0d
+`,
+		},
+		"JSON manifest without commit": {
+			fileName: "release.json",
+			contents: `{"releases": [{"target": "tgc", "releaseVersion": "v1.2.0", "previousReleaseVersion": "v1.1.0"}]}`,
+		},
+		"no releases": {
+			fileName:    "release.yaml",
+			contents:    "releaseVersion: v6.6.0\n",
+			expectError: true,
+		},
+		"unknown target": {
+			fileName:    "release.yaml",
+			contents:    "releases:\n  - target: alpha\n",
+			expectError: true,
+		},
+		"duplicate target": {
+			fileName:    "release.yaml",
+			contents:    "releases:\n  - target: ga\n  - target: GA\n",
+			expectError: true,
+		},
+		"new version isn't later than previous version": {
+			fileName:    "release.yaml",
+			contents:    "releaseVersion: v6.5.0\npreviousReleaseVersion: v6.6.0\nreleases:\n  - target: ga\n",
+			expectError: true,
+		},
+		"invalid version": {
+			fileName:    "release.yaml",
+			contents:    "releases:\n  - target: ga\n    releaseVersion: 6.6.0\n",
+			expectError: true,
+		},
+		"unknown key": {
+			fileName:    "release.yaml",
+			contents:    "releases:\n  - target: ga\n    commit: 33db873052ab34b92b5f6512bd874730a0f83164\n",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			_, err := LoadManifest(writeManifest(t, tc.fileName, tc.contents), testTargets)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+}
+
+func Test_Manifest_Release(t *testing.T) {
+	path := writeManifest(t, "release.yaml", `releaseVersion: v6.6.0
+previousReleaseVersion: v6.5.0
+releases:
+  - target: ga
+    commitSha: 33db873052ab34b92b5f6512bd874730a0f83164
+  - target: beta
+    commitSha: 6f1d6c1b4a2f4e0c8d1b3a5e7f9c0d2e4a6b8c0d
+    releaseVersion: v6.6.1
+`)
+	m, err := LoadManifest(path, testTargets)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r, err := m.Release("BETA")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if r.CommitSha != "6f1d6c1b4a2f4e0c8d1b3a5e7f9c0d2e4a6b8c0d" || r.ReleaseVersion != "v6.6.1" || r.PreviousReleaseVersion != "v6.5.0" {
+		t.Fatalf("unexpected release for beta: %#v", r)
+	}
+
+	if _, err := m.Release(""); err == nil {
+		t.Fatal("expected error when choosing from several releases without a target, but got none")
+	}
+	if _, err := m.Release("tgc"); err == nil {
+		t.Fatal("expected error for a target without a release, but got none")
+	}
+}
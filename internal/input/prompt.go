@@ -0,0 +1,57 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Option is a suggested answer to a prompt
+type Option struct {
+	// Label describes the option, e.g. a row of a table of commits
+	Label string
+	// Answer is the answer given when the option is chosen
+	Answer string
+}
+
+// Prompt is a question to ask the user
+type Prompt struct {
+	Question string
+	// Help is shown when the user enters ?
+	Help string
+
+	// Options are suggested answers, though other answers can still be entered. If Header is set the options are
+	// rows of a table, which are printed on the command line. Otherwise the question already lists the choices.
+	Header  string
+	Options []Option
+	// Default is the index of the option that's chosen by entering nothing, or -1 if there's no default
+	Default int
+
+	// Invalid is why the previous answer was invalid, or nil if the question hasn't been answered yet
+	Invalid error
+}
+
+// Prompter asks the user questions in place of the command line, e.g. in a terminal UI
+type Prompter interface {
+	// Ask returns the user's answer to the prompt
+	Ask(p Prompt) (string, error)
+	// Notify shows the user a message
+	Notify(message string)
+}
+
+// ErrNonInteractive is returned when an input is missing and there's nobody to ask for it
+var ErrNonInteractive = errors.New("running non-interactively")
+
+// NonInteractive is a Prompter for when there's nobody to answer questions, e.g. in CI. Asking a question fails instead
+// of waiting for an answer, and messages are written to Output.
+type NonInteractive struct {
+	Output io.Writer
+}
+
+func (n NonInteractive) Ask(p Prompt) (string, error) {
+	return "", fmt.Errorf("%w, so this question can't be answered: %s", ErrNonInteractive, p.Question)
+}
+
+func (n NonInteractive) Notify(message string) {
+	fmt.Fprintln(n.Output, message)
+}
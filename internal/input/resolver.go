@@ -0,0 +1,313 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/release_version"
+	"golang.org/x/mod/semver"
+)
+
+// Source describes where the value of an input came from
+type Source string
+
+const (
+	SOURCE_FLAG     Source = "flag"
+	SOURCE_MANIFEST Source = "manifest"
+	SOURCE_ENV      Source = "environment"
+	SOURCE_PROMPT   Source = "prompt"
+)
+
+// Values are the inputs supplied by a single source. Empty strings are values that weren't supplied.
+type Values struct {
+	Target                 string
+	CommitSha              string
+	ReleaseVersion         string
+	PreviousReleaseVersion string
+}
+
+type sourcedValues struct {
+	source Source
+	values Values
+}
+
+// FlagValues returns the inputs supplied by flags. The -ga and -beta flags are shorthands for -target.
+func FlagValues(target string, ga, beta bool, commitSha, releaseVersion, previousReleaseVersion string) (Values, error) {
+	v := Values{
+		CommitSha:              commitSha,
+		ReleaseVersion:         releaseVersion,
+		PreviousReleaseVersion: previousReleaseVersion,
+	}
+	if target != "" || ga || beta {
+		name, err := validateTargetFlags(target, ga, beta)
+		if err != nil {
+			return Values{}, err
+		}
+		v.Target = name
+	}
+	return v, nil
+}
+
+// EnvValues returns the inputs supplied by TPG_CLI_* environment variables
+func EnvValues() Values {
+	return Values{
+		Target:                 os.Getenv(config.ENV_PREFIX + "TARGET"),
+		CommitSha:              os.Getenv(config.ENV_PREFIX + "COMMIT_SHA"),
+		ReleaseVersion:         os.Getenv(config.ENV_PREFIX + "RELEASE_VERSION"),
+		PreviousReleaseVersion: os.Getenv(config.ENV_PREFIX + "PREV_RELEASE_VERSION"),
+	}
+}
+
+// Provenance records the value of an input and where it came from
+type Provenance struct {
+	Input  string
+	Value  string
+	Source Source
+}
+
+// LatestVersionFinder returns the version of the latest release of a target, in format v1.2.3
+type LatestVersionFinder func(t *config.Target) (string, error)
+
+// Resolver gathers each input from flags, a manifest, environment variables, or by prompting the user, in that order
+// of precedence, and records where each value came from.
+type Resolver struct {
+	Flags Values
+	// Manifest is nil if no manifest was supplied
+	Manifest *Manifest
+	Env      Values
+
+	// Handler prompts for any inputs that weren't supplied in another way
+	Handler *Handler
+	// LatestVersion is used to propose the next minor release when no versions were supplied
+	LatestVersion LatestVersionFinder
+	// Commits is used to list recent commits to pick from when no commit was supplied, and to check that a supplied
+	// commit is on the trunk branch. If nil, the user is asked for a SHA and supplied commits aren't checked.
+	Commits CommitFinder
+	// CommitCutoff is the most recent weekly cutoff for commits to be included in a release. The last commit before it
+	// is picked by default. If zero, the latest commit is picked by default.
+	CommitCutoff time.Time
+	// NonInteractive makes Resolve return an error listing every missing input, instead of prompting for them
+	NonInteractive bool
+
+	provenance []Provenance
+}
+
+// Resolve sets every field of the handler's Input, and validates the result
+func (r *Resolver) Resolve() error {
+	input := r.Handler.input
+
+	if err := r.resolveTarget(); err != nil {
+		return err
+	}
+
+	// Now the target is known, the manifest's release for that target can be used
+	sources := []sourcedValues{
+		{SOURCE_FLAG, r.Flags},
+	}
+	if r.Manifest != nil {
+		release, err := r.Manifest.Release(input.Target.Name)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, sourcedValues{SOURCE_MANIFEST, Values{CommitSha: release.CommitSha, ReleaseVersion: release.ReleaseVersion, PreviousReleaseVersion: release.PreviousReleaseVersion}})
+	}
+	sources = append(sources, sourcedValues{SOURCE_ENV, r.Env})
+
+	// Each value is taken from the first source that supplies it
+	first := func(get func(Values) string) (string, Source) {
+		for _, s := range sources {
+			if v := get(s.values); v != "" {
+				return v, s.source
+			}
+		}
+		return "", ""
+	}
+
+	newVersion, newSource := first(func(v Values) string { return v.ReleaseVersion })
+	oldVersion, oldSource := first(func(v Values) string { return v.PreviousReleaseVersion })
+	commit, commitSource := first(func(v Values) string { return v.CommitSha })
+	if r.NonInteractive {
+		var missing []string
+		if newVersion == "" {
+			missing = append(missing, "release version (-release_version or "+config.ENV_PREFIX+"RELEASE_VERSION)")
+		}
+		if oldVersion == "" {
+			missing = append(missing, "previous release version (-prev_release_version or "+config.ENV_PREFIX+"PREV_RELEASE_VERSION)")
+		}
+		if commit == "" {
+			missing = append(missing, "commit (-commit_sha or "+config.ENV_PREFIX+"COMMIT_SHA)")
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%w, so these inputs must be supplied by flags, a manifest or environment variables: %s", ErrNonInteractive, strings.Join(missing, ", "))
+		}
+	}
+
+	if err := r.resolveVersions(newVersion, newSource, oldVersion, oldSource); err != nil {
+		return err
+	}
+
+	if commit != "" {
+		if err := r.checkOnTrunk(commit, commitSource); err != nil {
+			return err
+		}
+		if err := input.SetCommit(commit); err != nil {
+			return err
+		}
+	} else {
+		if err := r.resolveCommit(); err != nil {
+			return err
+		}
+		commitSource = SOURCE_PROMPT
+	}
+	r.record("commit", input.CommitSha, commitSource)
+
+	return input.Validate()
+}
+
+func (r *Resolver) resolveTarget() error {
+	input := r.Handler.input
+
+	name, source := r.Flags.Target, SOURCE_FLAG
+	if name == "" && r.Manifest != nil && len(r.Manifest.Releases) == 1 {
+		// A manifest with releases for several targets needs the target to be chosen in another way
+		name, source = r.Manifest.Releases[0].Target, SOURCE_MANIFEST
+	}
+	if name == "" {
+		name, source = r.Env.Target, SOURCE_ENV
+	}
+
+	if name != "" {
+		if err := input.SetTarget(name, r.Handler.targets); err != nil {
+			return err
+		}
+	} else if r.NonInteractive {
+		return fmt.Errorf("%w, so the target must be supplied by a flag, a manifest or the %sTARGET environment variable", ErrNonInteractive, config.ENV_PREFIX)
+	} else {
+		if err := r.Handler.PromptAndProcessProviderChoiceInput(); err != nil {
+			return err
+		}
+		source = SOURCE_PROMPT
+	}
+	r.record("target", input.Target.Name, source)
+	return nil
+}
+
+// resolveVersions sets the release versions, prompting for whichever weren't supplied
+func (r *Resolver) resolveVersions(newVersion string, newSource Source, oldVersion string, oldSource Source) error {
+	input := r.Handler.input
+
+	// A supplied version that isn't valid would make every answer to the prompt for the other version fail
+	if newVersion != "" && !semver.IsValid(newVersion) {
+		return &failure.InputError{Err: fmt.Errorf("the release version %q from the %s isn't a semver version like v1.2.3", newVersion, newSource)}
+	}
+	if oldVersion != "" && !semver.IsValid(oldVersion) {
+		return &failure.InputError{Err: fmt.Errorf("the previous release version %q from the %s isn't a semver version like v1.2.3", oldVersion, oldSource)}
+	}
+
+	switch {
+	case newVersion != "" && oldVersion != "":
+		if err := input.SetReleaseVersions(newVersion, oldVersion); err != nil {
+			return err
+		}
+	case newVersion != "":
+		if err := r.Handler.PromptAndProcessPreviousReleaseVersionInput(newVersion); err != nil {
+			return err
+		}
+		oldSource = SOURCE_PROMPT
+	case oldVersion != "":
+		if err := r.Handler.PromptAndProcessNewReleaseVersionInput(oldVersion); err != nil {
+			return err
+		}
+		newSource = SOURCE_PROMPT
+	default:
+		// Prepare info about the last release and proposed new minor release versions
+		latestVersion, err := r.LatestVersion(input.Target)
+		if err != nil {
+			return err
+		}
+		proposedNextVersion, err := release_version.NextMinorVersion(latestVersion)
+		if err != nil {
+			return err
+		}
+		if err := r.Handler.PromptAndProcessReleaseVersionChoiceInput(latestVersion, proposedNextVersion); err != nil {
+			return err
+		}
+		newSource, oldSource = SOURCE_PROMPT, SOURCE_PROMPT
+	}
+
+	r.record("release version", input.ReleaseVersion, newSource)
+	r.record("previous release version", input.PreviousReleaseVersion, oldSource)
+	return nil
+}
+
+// resolveCommit asks the user to pick a recent commit from the trunk branch, or to enter a SHA if commits can't be listed
+func (r *Resolver) resolveCommit() error {
+	if r.Commits == nil {
+		return r.Handler.PromptAndProcessCommitChoiceInput()
+	}
+
+	t := r.Handler.input.Target
+	commits, err := r.Commits.RecentCommits(t, DEFAULT_COMMIT_PICKER_SIZE)
+	if err != nil || len(commits) == 0 {
+		r.Handler.Notify(fmt.Sprintf("Unable to list recent commits on the %s branch of %s: %v", t.TrunkBranch, t.Repo, err))
+		return r.Handler.PromptAndProcessCommitChoiceInput()
+	}
+
+	defaultIndex := defaultCommitIndex(commits, r.CommitCutoff)
+	var note string
+	if !r.CommitCutoff.IsZero() {
+		cutoff := r.CommitCutoff.Format("Mon 2 Jan 15:04 MST")
+		if defaultIndex >= 0 {
+			note = fmt.Sprintf("The commit marked * is the last commit before the cutoff at %s", cutoff)
+		} else {
+			note = fmt.Sprintf("None of these commits were made before the cutoff at %s", cutoff)
+		}
+	}
+	return r.Handler.PromptAndProcessCommitPickerInput(commits, defaultIndex, note, func(sha string) (bool, error) {
+		return r.Commits.IsOnTrunk(t, sha)
+	})
+}
+
+// checkOnTrunk returns an error if a supplied commit isn't on the trunk branch, e.g. because it's from a fork or the
+// wrong provider. Commits picked from the list of recent commits are checked by the picker.
+func (r *Resolver) checkOnTrunk(sha string, source Source) error {
+	if r.Commits == nil {
+		return nil
+	}
+	t := r.Handler.input.Target
+	onTrunk, err := r.Commits.IsOnTrunk(t, sha)
+	if err != nil {
+		return fmt.Errorf("unable to find commit %s from the %s in %s: %w", sha, source, t.Repo, err)
+	}
+	if !onTrunk {
+		return fmt.Errorf("commit %s from the %s isn't on the %s branch of %s or wasn't found in the clone, check it's from the right repository", sha, source, t.TrunkBranch, t.Repo)
+	}
+	return nil
+}
+
+func (r *Resolver) record(name, value string, source Source) {
+	r.provenance = append(r.provenance, Provenance{Input: name, Value: value, Source: source})
+}
+
+// Provenance returns the resolved inputs and where they came from, in the order they were resolved
+func (r *Resolver) Provenance() []Provenance {
+	return r.provenance
+}
+
+// ProvenanceTable returns the resolved inputs and where they came from, formatted as a table for printing to the terminal
+func (r *Resolver) ProvenanceTable() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "INPUT\tVALUE\tSOURCE")
+	for _, p := range r.provenance {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Input, p.Value, p.Source)
+	}
+	w.Flush()
+	return b.String()
+}
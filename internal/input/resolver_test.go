@@ -0,0 +1,288 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+)
+
+func Test_Resolver_Resolve(t *testing.T) {
+
+	commit := "33db873052ab34b92b5f6512bd874730a0f83164"
+	manifest := &Manifest{
+		Releases: []ManifestRelease{
+			{Target: "ga", CommitSha: "6f1d6c1b4a2f4e0c8d1b3a5e7f9c0d2e4a6b8c0d", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+		},
+	}
+
+	cases := map[string]struct {
+		flags     Values
+		manifest  *Manifest
+		env       Values
+		userInput string
+
+		expectedInput      Input
+		expectedProvenance []Source // target, release version, previous release version, commit
+		expectError        bool
+	}{
+		"all inputs from flags": {
+			flags:              Values{Target: "beta", CommitSha: commit, ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectedInput:      Input{CommitSha: commit, ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectedProvenance: []Source{SOURCE_FLAG, SOURCE_FLAG, SOURCE_FLAG, SOURCE_FLAG},
+		},
+		"flags take precedence over manifest, which takes precedence over environment": {
+			flags:              Values{ReleaseVersion: "v6.7.0"},
+			manifest:           manifest,
+			env:                Values{Target: "beta", CommitSha: commit},
+			expectedInput:      Input{CommitSha: "6f1d6c1b4a2f4e0c8d1b3a5e7f9c0d2e4a6b8c0d", ReleaseVersion: "v6.7.0", PreviousReleaseVersion: "v6.5.0"},
+			expectedProvenance: []Source{SOURCE_MANIFEST, SOURCE_FLAG, SOURCE_MANIFEST, SOURCE_MANIFEST},
+		},
+		"only the release version supplied prompts for the previous version": {
+			flags:              Values{Target: "ga", CommitSha: commit, ReleaseVersion: "v6.6.0"},
+			userInput:          "v6.5.0\n",
+			expectedInput:      Input{CommitSha: commit, ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectedProvenance: []Source{SOURCE_FLAG, SOURCE_FLAG, SOURCE_PROMPT, SOURCE_FLAG},
+		},
+		"only the previous version supplied prompts for the release version": {
+			env:                Values{Target: "ga", PreviousReleaseVersion: "v6.5.0"},
+			userInput:          "v6.5.1\n" + commit + "\n",
+			expectedInput:      Input{CommitSha: commit, ReleaseVersion: "v6.5.1", PreviousReleaseVersion: "v6.5.0"},
+			expectedProvenance: []Source{SOURCE_ENV, SOURCE_PROMPT, SOURCE_ENV, SOURCE_PROMPT},
+		},
+		"nothing supplied prompts for everything": {
+			userInput:          "ga\ny\n" + commit + "\n",
+			expectedInput:      Input{CommitSha: commit, ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectedProvenance: []Source{SOURCE_PROMPT, SOURCE_PROMPT, SOURCE_PROMPT, SOURCE_PROMPT},
+		},
+		"manifest without a release for the chosen target": {
+			flags:       Values{Target: "beta"},
+			manifest:    manifest,
+			expectError: true,
+		},
+		"invalid versions from flags": {
+			flags:       Values{Target: "ga", CommitSha: commit, ReleaseVersion: "v6.5.0", PreviousReleaseVersion: "v6.6.0"},
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+			handler.reader = bufio.NewReader(strings.NewReader(tc.userInput))
+
+			r := Resolver{
+				Flags:    tc.flags,
+				Manifest: tc.manifest,
+				Env:      tc.env,
+				Handler:  &handler,
+				LatestVersion: func(t *config.Target) (string, error) {
+					return "v6.5.0", nil
+				},
+			}
+
+			err := r.Resolve()
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if tc.expectError {
+				return
+			}
+
+			input.Target = nil // checked by provenance
+			if input != tc.expectedInput {
+				t.Fatalf("wanted input %#v, got %#v", tc.expectedInput, input)
+			}
+			var sources []Source
+			for _, p := range r.Provenance() {
+				sources = append(sources, p.Source)
+			}
+			if !reflect.DeepEqual(sources, tc.expectedProvenance) {
+				t.Fatalf("wanted sources %v, got %v:\n%s", tc.expectedProvenance, sources, r.ProvenanceTable())
+			}
+		})
+	}
+}
+
+func Test_Resolver_Resolve_latestVersionError(t *testing.T) {
+	input := Input{}
+	handler := NewHandler(&input, testTargets)
+
+	r := Resolver{
+		Flags:   Values{Target: "ga"},
+		Handler: &handler,
+		LatestVersion: func(t *config.Target) (string, error) {
+			return "", errors.New("no network")
+		},
+	}
+	if err := r.Resolve(); err == nil {
+		t.Fatal("expected error but got none")
+	}
+}
+
+func Test_Resolver_Resolve_invalidSuppliedVersion(t *testing.T) {
+	commit := "33db873052ab34b92b5f6512bd874730a0f83164"
+
+	cases := map[string]struct {
+		flags Values
+		env   Values
+	}{
+		"invalid release version from a flag": {
+			flags: Values{Target: "ga", CommitSha: commit, ReleaseVersion: "6.6.0"},
+		},
+		"invalid previous release version from the environment": {
+			env: Values{Target: "ga", CommitSha: commit, PreviousReleaseVersion: "latest"},
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+			// Nothing to read, so prompting for the other version would fail with a different error
+			handler.reader = bufio.NewReader(strings.NewReader(""))
+
+			r := Resolver{Flags: tc.flags, Env: tc.env, Handler: &handler}
+			err := r.Resolve()
+			if failure.Code(err) != failure.EXIT_INPUT {
+				t.Fatalf("expected an input error without prompting, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_Resolver_Resolve_commitOnTrunk(t *testing.T) {
+	cases := map[string]struct {
+		flags       Values
+		manifest    *Manifest
+		env         Values
+		expectError bool
+	}{
+		"commit from a flag on the trunk branch": {
+			flags: Values{Target: "ga", CommitSha: "d4d4d4d4d4d4d4d4d4d4d4d4d4d4d4d4d4d4d4d4", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+		},
+		"commit from a flag that isn't on the trunk branch": {
+			flags:       Values{Target: "ga", CommitSha: "33db873052ab34b92b5f6512bd874730a0f83164", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectError: true,
+		},
+		"commit from a manifest that isn't on the trunk branch": {
+			manifest: &Manifest{Releases: []ManifestRelease{
+				{Target: "ga", CommitSha: "33db873052ab34b92b5f6512bd874730a0f83164", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			}},
+			expectError: true,
+		},
+		"commit from the environment that isn't on the trunk branch": {
+			env:         Values{Target: "ga", CommitSha: "33db873052ab34b92b5f6512bd874730a0f83164", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+			r := Resolver{Flags: tc.flags, Manifest: tc.manifest, Env: tc.env, Handler: &handler, Commits: fakeCommitFinder{}}
+
+			err := r.Resolve()
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+		})
+	}
+}
+
+func Test_FlagValues(t *testing.T) {
+	v, err := FlagValues("", false, true, "", "v6.6.0", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v.Target != "beta" || v.ReleaseVersion != "v6.6.0" {
+		t.Fatalf("unexpected values: %#v", v)
+	}
+
+	v, err = FlagValues("", false, false, "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v.Target != "" {
+		t.Fatalf("expected no target when no flags are set, got %q", v.Target)
+	}
+
+	if _, err := FlagValues("tgc", true, false, "", "", ""); err == nil {
+		t.Fatal("expected error when -target and -ga are both set, but got none")
+	}
+}
+
+func Test_Resolver_Resolve_nonInteractive(t *testing.T) {
+	commit := "33db873052ab34b92b5f6512bd874730a0f83164"
+
+	cases := map[string]struct {
+		flags           Values
+		env             Values
+		expectedMissing []string
+		expectError     bool
+	}{
+		"all inputs supplied": {
+			flags: Values{Target: "ga", ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			env:   Values{CommitSha: commit},
+		},
+		"missing target": {
+			flags:           Values{CommitSha: commit, ReleaseVersion: "v6.6.0", PreviousReleaseVersion: "v6.5.0"},
+			expectedMissing: []string{"TPG_CLI_TARGET"},
+			expectError:     true,
+		},
+		"every missing input is listed": {
+			flags:           Values{Target: "ga", ReleaseVersion: "v6.6.0"},
+			expectedMissing: []string{"-prev_release_version", "-commit_sha"},
+			expectError:     true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			input := Input{}
+			handler := NewHandler(&input, testTargets)
+			prompter := &fakePrompter{}
+			handler.SetPrompter(prompter)
+
+			r := Resolver{
+				Flags:          tc.flags,
+				Env:            tc.env,
+				Handler:        &handler,
+				NonInteractive: true,
+				LatestVersion: func(t *config.Target) (string, error) {
+					return "", errors.New("latest version shouldn't be looked up when running non-interactively")
+				},
+			}
+			err := r.Resolve()
+			if len(prompter.prompts) != 0 {
+				t.Fatalf("expected no prompts, got %#v", prompter.prompts)
+			}
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if err != nil && !errors.Is(err, ErrNonInteractive) {
+				t.Fatalf("expected error to wrap ErrNonInteractive, got %s", err)
+			}
+			for _, m := range tc.expectedMissing {
+				if !strings.Contains(err.Error(), m) {
+					t.Fatalf("expected error to mention %q, got %s", m, err)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
 	"golang.org/x/mod/semver"
 )
 
@@ -18,26 +19,37 @@ func (v compositeValidationError) Error() string {
 	return b.String()
 }
 
-func validateGaBetaInputs(ga, beta bool) error {
-	if ga && beta {
-		return fmt.Errorf("you should provide only one of the -ga and -beta flags")
+func validateTargetFlags(target string, ga, beta bool) (string, error) {
+	var names []string
+	if target != "" {
+		names = append(names, target)
 	}
-	if !ga && !beta {
-		return fmt.Errorf("you need to provide at least one of the -ga and -beta flags")
+	if ga {
+		names = append(names, "ga")
+	}
+	if beta {
+		names = append(names, "beta")
 	}
-	return nil
-}
 
-func validateProviderInputs(providerVersion string) error {
-	pv := strings.ToLower(providerVersion)
-	switch pv {
-	case "ga":
-		return nil
-	case "beta":
-		return nil
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("you need to provide one of the -target, -ga and -beta flags")
+	case 1:
+		return names[0], nil
 	default:
-		return fmt.Errorf("bad provider version input, please answer 'ga' or 'beta' ")
+		return "", fmt.Errorf("you should provide only one of the -target, -ga and -beta flags")
+	}
+}
+
+func validateTargetInput(name string, targets []config.Target) (*config.Target, error) {
+	var names []string
+	for i := range targets {
+		if strings.EqualFold(targets[i].Name, name) {
+			return &targets[i], nil
+		}
+		names = append(names, targets[i].Name)
 	}
+	return nil, fmt.Errorf("bad provider input, please answer one of: %s", strings.Join(names, ", "))
 }
 
 func validateCommitShaInput(commitSha string) error {
@@ -45,3 +45,55 @@ func Test_ValidateVersionInputs(t *testing.T) {
 		})
 	}
 }
+
+func Test_ValidateTargetFlags(t *testing.T) {
+	cases := map[string]struct {
+		target       string
+		ga           bool
+		beta         bool
+		expectedName string
+		expectErr    bool
+	}{
+		"-ga": {
+			ga:           true,
+			expectedName: "ga",
+		},
+		"-beta": {
+			beta:         true,
+			expectedName: "beta",
+		},
+		"-target": {
+			target:       "tgc",
+			expectedName: "tgc",
+		},
+		"no flags": {
+			expectErr: true,
+		},
+		"-ga and -beta": {
+			ga:        true,
+			beta:      true,
+			expectErr: true,
+		},
+		"-target and -ga": {
+			target:    "ga",
+			ga:        true,
+			expectErr: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			name, err := validateTargetFlags(tc.target, tc.ga, tc.beta)
+
+			if err != nil && !tc.expectErr {
+				t.Fatalf("encountered errors when none were expected: %v", err)
+			}
+			if err == nil && tc.expectErr {
+				t.Fatalf("expected errors but none were returned from the function")
+			}
+			if name != tc.expectedName {
+				t.Fatalf("wanted %q, got %q", tc.expectedName, name)
+			}
+		})
+	}
+}
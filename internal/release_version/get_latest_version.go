@@ -1,51 +1,101 @@
 package release_version
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"regexp"
 	"strconv"
 	"time"
 
 	"golang.org/x/mod/semver"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
 )
 
 type ReleaseQuery struct {
-	client        *http.Client
+	client *http.Client
+	// baseURL is the GitHub API, which is replaced in tests
+	baseURL       string
 	owner         string
 	repo          string
 	latestRelease string
+	// retry is how the request is retried if it fails because of a network problem or a server error
+	retry retry.Policy
 }
 
+var GITHUB_API_URL = "https://api.github.com"
+
 type LatestReleaseResp struct {
 	TagName string `json:"tag_name"`
 }
 
 func New(owner, repo string) ReleaseQuery {
 	return ReleaseQuery{
-		client: &http.Client{Timeout: 10 * time.Second},
-		owner:  owner,
-		repo:   repo,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: GITHUB_API_URL,
+		owner:   owner,
+		repo:    repo,
+		retry:   retry.DEFAULT_POLICY,
 	}
 }
 
-func (c *ReleaseQuery) GetLastVersionFromGitHub() (string, error) {
+// GetLastVersionFromGitHub returns the tag of the latest release. The request and any retries stop if ctx is cancelled,
+// e.g. when the user presses Ctrl-C.
+func (c *ReleaseQuery) GetLastVersionFromGitHub(ctx context.Context) (string, error) {
 	// return result from previous run, if present
 	if c.latestRelease != "" {
 		return c.latestRelease, nil
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", c.owner, c.repo)
-	resp, err := c.client.Get(url)
+	var tagName string
+	err := c.retry.Do(ctx,
+		func() error {
+			var err error
+			tagName, err = c.getLatestRelease(ctx)
+			return err
+		},
+		retry.IsTransient,
+		func(err error, wait time.Duration, attempt int) {
+			log.Printf("%s: %s", c.retry.Message("Getting the latest release from GitHub", wait, attempt), err)
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	// memo
+	c.latestRelease = tagName
+
+	return tagName, nil
+}
+
+func (c *ReleaseQuery) getLatestRelease(ctx context.Context) (string, error) {
+	baseURL := c.baseURL
+	if baseURL == "" {
+		baseURL = GITHUB_API_URL
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", baseURL, c.owner, c.repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error getting latest release from github.com/%s/%s : %w", c.owner, c.repo, err)
+	}
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("error getting latest release from github.com/%s/%s : %w", c.owner, c.repo, err)
 	}
 	if resp.StatusCode != 200 {
 		data, _ := io.ReadAll(resp.Body)
 		defer resp.Body.Close()
-		return "", fmt.Errorf("got a non-200 response: status '%s', body '%s'", resp.Status, data)
+		err := fmt.Errorf("got a non-200 response: status '%s', body '%s'", resp.Status, data)
+		// Server errors are usually temporary, unlike a missing repository or a rate limit
+		if resp.StatusCode >= 500 {
+			return "", &retry.TransientError{Err: err}
+		}
+		return "", err
 	}
 
 	defer resp.Body.Close()
@@ -56,9 +106,6 @@ func (c *ReleaseQuery) GetLastVersionFromGitHub() (string, error) {
 		return "", fmt.Errorf("error parsing response body : %w", err)
 	}
 
-	// memo
-	c.latestRelease = data.TagName
-
 	return data.TagName, nil
 }
 
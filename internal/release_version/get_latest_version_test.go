@@ -1,11 +1,16 @@
 package release_version
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"golang.org/x/mod/semver"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
 )
 
 func TestGetLastVersionFromGitHub(t *testing.T) {
@@ -16,7 +21,7 @@ func TestGetLastVersionFromGitHub(t *testing.T) {
 			owner:  "hashicorp",
 			repo:   "terraform-provider-google",
 		}
-		ver, err := c.GetLastVersionFromGitHub()
+		ver, err := c.GetLastVersionFromGitHub(context.Background())
 		if err != nil {
 			t.Fatalf("unexpected error(s) encountered: %v", err)
 		}
@@ -27,6 +32,106 @@ func TestGetLastVersionFromGitHub(t *testing.T) {
 	})
 }
 
+func TestGetLastVersionFromGitHub_retries(t *testing.T) {
+	cases := map[string]struct {
+		statuses         []int
+		expectedRequests int
+		expectError      bool
+	}{
+		"retries server errors": {
+			statuses:         []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			expectedRequests: 3,
+		},
+		"gives up after all attempts": {
+			statuses:         []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			expectedRequests: 3,
+			expectError:      true,
+		},
+		"doesn't retry a missing repository": {
+			statuses:         []int{http.StatusNotFound, http.StatusOK},
+			expectedRequests: 1,
+			expectError:      true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statuses[requests])
+				requests++
+				w.Write([]byte(`{"tag_name": "v6.5.0"}`))
+			}))
+			defer server.Close()
+
+			c := ReleaseQuery{
+				client:  server.Client(),
+				baseURL: server.URL,
+				owner:   "hashicorp",
+				repo:    "terraform-provider-google",
+				retry:   retry.Policy{Attempts: 3, Backoff: time.Millisecond},
+			}
+			ver, err := c.GetLastVersionFromGitHub(context.Background())
+			if requests != tc.expectedRequests {
+				t.Fatalf("wanted %d requests, got %d", tc.expectedRequests, requests)
+			}
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if ver != "v6.5.0" {
+				t.Fatalf("wanted v6.5.0, got %s", ver)
+			}
+		})
+	}
+}
+
+func TestGetLastVersionFromGitHub_cancelled(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// Cancelled while waiting to retry, which would otherwise take a minute
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := ReleaseQuery{
+		client:  server.Client(),
+		baseURL: server.URL,
+		owner:   "hashicorp",
+		repo:    "terraform-provider-google",
+		retry:   retry.Policy{Attempts: 3, Backoff: time.Minute},
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := c.GetLastVersionFromGitHub(ctx)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the retries to stop when cancelled, took %s", elapsed)
+	}
+	if requests != 1 {
+		t.Fatalf("wanted 1 request, got %d", requests)
+	}
+
+	// Nothing is requested once cancelled
+	if _, err := c.GetLastVersionFromGitHub(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the request to be cancelled, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("wanted no more requests after cancelling, got %d", requests-1)
+	}
+}
+
 func TestNextMinorVersion(t *testing.T) {
 	t.Run("can suggest the next minor version as the next version to release", func(t *testing.T) {
 		ver, err := NextMinorVersion("v1.2.3")
@@ -0,0 +1,125 @@
+package remediation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Remediation is advice for fixing a recognised git or GitHub failure
+type Remediation struct {
+	// Problem describes the failure in plain words
+	Problem string
+	// Advice is what to do about the failure
+	Advice string
+	// Fix is a git command that fixes the failure, or nil if there's no fix that's safe to make automatically
+	Fix *Fix
+}
+
+// Fix is a git command that fixes a failure without losing any work, so it can be offered to the user
+type Fix struct {
+	// Description completes the question "Do you want to ...?"
+	Description string
+	// Args are the arguments to git
+	Args []string
+}
+
+// Command returns the fix as a command that can be run in a terminal
+func (f Fix) Command() string {
+	return "git " + strings.Join(f.Args, " ")
+}
+
+// String describes the problem and what to do about it, e.g. for appending to an error
+func (r Remediation) String() string {
+	s := fmt.Sprintf("Hint: %s %s", r.Problem, r.Advice)
+	if r.Fix != nil {
+		s += fmt.Sprintf(" This can be fixed by running `%s`.", r.Fix.Command())
+	}
+	return s
+}
+
+// pattern matches output from a failed command, e.g. stderr, and returns the remediation for it
+type pattern struct {
+	re *regexp.Regexp
+	// remediation returns advice for the failure, using any submatches of re
+	remediation func(matches []string) Remediation
+}
+
+// catalogue contains the failures that new release rotation members most often need help with, in the order they're
+// checked. Patterns are checked against the whole output, so more specific patterns must come first.
+var catalogue = []pattern{
+	{
+		re: regexp.MustCompile(`a branch named '([^']+)' already exists`),
+		remediation: func(matches []string) Remediation {
+			return Remediation{
+				Problem: fmt.Sprintf("The %s branch already exists in your local clone, e.g. from an earlier attempt at this release.", matches[1]),
+				Advice:  fmt.Sprintf("Check the release version is correct. If the earlier attempt wasn't pushed, delete the branch with `git branch -D %s` and try again.", matches[1]),
+			}
+		},
+	},
+	{
+		re: regexp.MustCompile(`unknown revision|bad revision|did not match any file\(s\) known to git|not a valid object name|Needed a single revision`),
+		remediation: func(matches []string) Remediation {
+			return Remediation{
+				Problem: "A commit, tag or branch doesn't exist in your local clone.",
+				Advice:  "Check the commit SHA and release versions are correct, and fetch the latest commits and tags from your remotes.",
+				Fix: &Fix{
+					Description: "fetch the latest commits and tags from your remotes",
+					Args:        []string{"fetch", "--all", "--tags"},
+				},
+			}
+		},
+	},
+	{
+		re: regexp.MustCompile(`non-fast-forward|\(fetch first\)|Updates were rejected`),
+		remediation: func(matches []string) Remediation {
+			return Remediation{
+				Problem: "The branch on the remote has commits that your local branch doesn't, e.g. the release branch was already pushed.",
+				Advice:  "Don't force push. Check the branch on GitHub to see who pushed it and whether the release is already in progress.",
+			}
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)authentication failed|permission denied \(publickey\)|could not read username|terminal prompts disabled|the requested url returned error: 403`),
+		remediation: func(matches []string) Remediation {
+			return Remediation{
+				Problem: "Git couldn't authenticate with the remote.",
+				Advice:  "Check that your SSH key or credential helper is set up, and that you have permission to push to the repository, e.g. with `git push --dry-run`.",
+			}
+		},
+	},
+	{
+		re: regexp.MustCompile(`local changes to the following files would be overwritten|untracked working tree files would be overwritten|Please commit your changes or stash them`),
+		remediation: func(matches []string) Remediation {
+			return Remediation{
+				Problem: "Your local clone has changes that would be overwritten.",
+				Advice:  "Commit or stash your changes, and get them back after the release with `git stash pop`.",
+				Fix: &Fix{
+					Description: "stash your local changes",
+					Args:        []string{"stash", "push", "--include-untracked"},
+				},
+			}
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)rate limit`),
+		remediation: func(matches []string) Remediation {
+			return Remediation{
+				Problem: "GitHub's API rate limit was reached.",
+				Advice:  "Requests without a token are limited to 60 an hour, so make sure a GitHub token is configured, or wait an hour and try again.",
+			}
+		},
+	},
+}
+
+// Find returns advice for the failure described by output, e.g. a command's stderr, or nil if the failure isn't
+// recognised
+func Find(output string) *Remediation {
+	for _, p := range catalogue {
+		if matches := p.re.FindStringSubmatch(output); matches != nil {
+			r := p.remediation(matches)
+			return &r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package remediation
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Find(t *testing.T) {
+	cases := map[string]struct {
+		output          string
+		expectedProblem string
+		expectedAdvice  string
+		expectedFix     string
+	}{
+		"branch already exists": {
+			output:          "fatal: a branch named 'release-6.6.0' already exists",
+			expectedProblem: "The release-6.6.0 branch already exists",
+			expectedAdvice:  "`git branch -D release-6.6.0`",
+		},
+		"unknown revision": {
+			output:          "fatal: ambiguous argument 'v6.5.0': unknown revision or path not in the working tree.",
+			expectedProblem: "doesn't exist in your local clone",
+			expectedFix:     "git fetch --all --tags",
+		},
+		"unknown commit when checking out": {
+			output:          "error: pathspec '33db873' did not match any file(s) known to git",
+			expectedProblem: "doesn't exist in your local clone",
+			expectedFix:     "git fetch --all --tags",
+		},
+		"non-fast-forward": {
+			output:          " ! [rejected]        release-6.6.0 -> release-6.6.0 (non-fast-forward)\nerror: failed to push some refs",
+			expectedProblem: "The branch on the remote has commits",
+			expectedAdvice:  "Don't force push",
+		},
+		"authentication failed": {
+			output:          "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/hashicorp/terraform-provider-google.git/'",
+			expectedProblem: "couldn't authenticate",
+		},
+		"ssh key not set up": {
+			output:          "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.",
+			expectedProblem: "couldn't authenticate",
+		},
+		"local changes would be overwritten": {
+			output:          "error: Your local changes to the following files would be overwritten by checkout:\n\tCHANGELOG.md\nPlease commit your changes or stash them before you switch branches.",
+			expectedProblem: "changes that would be overwritten",
+			expectedFix:     "git stash push --include-untracked",
+		},
+		"rate limit exceeded": {
+			output:          `got a non-200 response: status '403 Forbidden', body '{"message":"API rate limit exceeded for 192.0.2.1."}'`,
+			expectedProblem: "rate limit",
+			expectedAdvice:  "GitHub token",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			r := Find(tc.output)
+			if r == nil {
+				t.Fatal("expected the failure to be recognised")
+			}
+			if !strings.Contains(r.Problem, tc.expectedProblem) {
+				t.Fatalf("expected problem to contain %q, got %q", tc.expectedProblem, r.Problem)
+			}
+			if !strings.Contains(r.Advice, tc.expectedAdvice) {
+				t.Fatalf("expected advice to contain %q, got %q", tc.expectedAdvice, r.Advice)
+			}
+			switch {
+			case tc.expectedFix == "" && r.Fix != nil:
+				t.Fatalf("expected no fix, got %q", r.Fix.Command())
+			case tc.expectedFix != "" && r.Fix == nil:
+				t.Fatalf("expected fix %q, got none", tc.expectedFix)
+			case tc.expectedFix != "" && r.Fix.Command() != tc.expectedFix:
+				t.Fatalf("wanted fix %q, got %q", tc.expectedFix, r.Fix.Command())
+			}
+		})
+	}
+}
+
+func Test_Find_unrecognised(t *testing.T) {
+	if r := Find("fatal: not a git repository (or any of the parent directories): .git"); r != nil {
+		t.Fatalf("expected no remediation, got %q", r)
+	}
+}
+
+func Test_Remediation_String(t *testing.T) {
+	r := Find("Please commit your changes or stash them before you switch branches.")
+	expected := "Hint: Your local clone has changes that would be overwritten. Commit or stash your changes, and get them back after the release with `git stash pop`. This can be fixed by running `git stash push --include-untracked`."
+	if r.String() != expected {
+		t.Fatalf("wanted %q, got %q", expected, r.String())
+	}
+}
@@ -0,0 +1,127 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+// Policy describes how many times an operation that uses the network is run, and how long to wait between attempts
+type Policy struct {
+	// Attempts is the most times the operation is run, including the first time. Less than 2 means no retries.
+	Attempts int
+	// Backoff is how long to wait before the first retry. The wait doubles for each retry after that, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the fraction of each wait that's random, e.g. 0.25 for a wait of 4s means a wait of 3-5s, so that
+	// retries don't line up with the network problem that caused them
+	Jitter float64
+}
+
+// DEFAULT_POLICY rides out a flaky connection, e.g. a VPN reconnecting, without making the user wait long for a
+// problem that won't go away
+var DEFAULT_POLICY = Policy{
+	Attempts:   4,
+	Backoff:    2 * time.Second,
+	MaxBackoff: 30 * time.Second,
+	Jitter:     0.25,
+}
+
+// NONE runs operations once, without retrying them
+var NONE = Policy{Attempts: 1}
+
+// Wait returns how long to wait before the nth retry, counting from 1
+func (p Policy) Wait(n int) time.Duration {
+	wait := p.Backoff
+	for i := 1; i < n && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		wait = time.Duration(float64(wait) * (1 - p.Jitter + 2*p.Jitter*rand.Float64()))
+	}
+	return wait
+}
+
+// Do runs fn until it succeeds, fails with an error that isTransient says isn't worth retrying, or the attempts run
+// out. onRetry is called before each wait, e.g. to tell the user what's happening, and can be nil. Waiting stops early
+// if ctx is cancelled.
+func (p Policy) Do(ctx context.Context, fn func() error, isTransient func(error) bool, onRetry func(err error, wait time.Duration, attempt int)) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := p.Wait(attempt)
+		if onRetry != nil {
+			onRetry(err, wait, attempt)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// Message describes a retry for the user, e.g. for use in an onRetry function
+func (p Policy) Message(operation string, wait time.Duration, attempt int) string {
+	return fmt.Sprintf("%s failed with an error that might be temporary, trying again in %s (attempt %d of %d)", operation, wait.Round(100*time.Millisecond), attempt+1, p.Attempts)
+}
+
+// TransientError marks an error as worth retrying, e.g. a 5xx response from an API
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string { return e.Err.Error() }
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// IsTransient returns whether an error from a network request is likely to go away if the request is made again:
+// timeouts, dropped connections and errors marked with TransientError. Cancelling isn't transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var transientErr *TransientError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &transientErr):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	return false
+}
+
+// transientOutputRE matches the output of git and other commands when they fail because of the network, rather than
+// because of a problem like a branch that already exists
+var transientOutputRE = regexp.MustCompile(`(?i)` +
+	`connection reset|connection refused|connection timed out|operation timed out|timed out|timeout|` +
+	`could not resolve host|temporary failure in name resolution|network is unreachable|` +
+	`the remote end hung up unexpectedly|early eof|unexpected disconnect|rpc failed|broken pipe|` +
+	`ssh: connect to host|kex_exchange_identification|connection closed by remote host|` +
+	`gnutls_handshake\(\) failed|tls handshake|` +
+	`returned error: 5\d\d|\b50[0234]\b [a-z ]*(error|gateway|unavailable)`)
+
+// IsTransientOutput returns whether a command's output, e.g. stderr, shows that it failed because of the network
+func IsTransientOutput(output string) bool {
+	return transientOutputRE.MatchString(output)
+}
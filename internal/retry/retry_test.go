@@ -0,0 +1,196 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_Policy_Wait(t *testing.T) {
+	p := Policy{Attempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	cases := map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 5 * time.Second,
+		9: 5 * time.Second,
+	}
+	for n, expected := range cases {
+		if wait := p.Wait(n); wait != expected {
+			t.Fatalf("wanted wait %s before retry %d, got %s", expected, n, wait)
+		}
+	}
+}
+
+func Test_Policy_Wait_jitter(t *testing.T) {
+	p := Policy{Attempts: 5, Backoff: 4 * time.Second, MaxBackoff: 30 * time.Second, Jitter: 0.25}
+	for i := 0; i < 100; i++ {
+		if wait := p.Wait(1); wait < 3*time.Second || wait > 5*time.Second {
+			t.Fatalf("wanted a wait between 3s and 5s, got %s", wait)
+		}
+	}
+}
+
+func Test_Policy_Do(t *testing.T) {
+	transientErr := &TransientError{Err: errors.New("got a non-200 response: status '502 Bad Gateway'")}
+	logicalErr := errors.New("a branch named 'release-6.6.0' already exists")
+
+	cases := map[string]struct {
+		errs             []error
+		expectedAttempts int
+		expectedErr      error
+	}{
+		"succeeds first time": {
+			errs:             []error{nil},
+			expectedAttempts: 1,
+		},
+		"succeeds after transient errors": {
+			errs:             []error{transientErr, transientErr, nil},
+			expectedAttempts: 3,
+		},
+		"gives up after all attempts": {
+			errs:             []error{transientErr, transientErr, transientErr, transientErr},
+			expectedAttempts: 3,
+			expectedErr:      transientErr,
+		},
+		"doesn't retry errors that aren't transient": {
+			errs:             []error{logicalErr, nil},
+			expectedAttempts: 1,
+			expectedErr:      logicalErr,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			p := Policy{Attempts: 3, Backoff: time.Millisecond}
+			attempts, retries := 0, 0
+			err := p.Do(context.Background(),
+				func() error {
+					attempts++
+					return tc.errs[attempts-1]
+				},
+				IsTransient,
+				func(err error, wait time.Duration, attempt int) {
+					retries++
+				},
+			)
+			if err != tc.expectedErr {
+				t.Fatalf("wanted error %v, got %v", tc.expectedErr, err)
+			}
+			if attempts != tc.expectedAttempts {
+				t.Fatalf("wanted %d attempts, got %d", tc.expectedAttempts, attempts)
+			}
+			if retries != attempts-1 {
+				t.Fatalf("wanted onRetry to be called %d times, got %d", attempts-1, retries)
+			}
+		})
+	}
+}
+
+func Test_Policy_Do_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := Policy{Attempts: 3, Backoff: time.Hour}
+	attempts := 0
+	err := p.Do(ctx,
+		func() error {
+			attempts++
+			return &TransientError{Err: errors.New("connection reset by peer")}
+		},
+		IsTransient,
+		func(err error, wait time.Duration, attempt int) {
+			cancel()
+		},
+	)
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected to stop waiting when cancelled after 1 attempt, got %d attempts and error %v", attempts, err)
+	}
+}
+
+func Test_IsTransient(t *testing.T) {
+	cases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"marked as transient": {
+			err:      fmt.Errorf("error getting latest release: %w", &TransientError{Err: errors.New("503 Service Unavailable")}),
+			expected: true,
+		},
+		"connection reset": {
+			err:      &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+			expected: true,
+		},
+		"timeout": {
+			err:      fmt.Errorf("error getting latest release: %w", context.DeadlineExceeded),
+			expected: true,
+		},
+		"cancelled": {
+			err:      fmt.Errorf("%w: %w", context.Canceled, &TransientError{Err: errors.New("connection reset by peer")}),
+			expected: false,
+		},
+		"logical error": {
+			err:      errors.New("got a non-200 response: status '404 Not Found'"),
+			expected: false,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			if got := IsTransient(tc.err); got != tc.expected {
+				t.Fatalf("wanted %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func Test_IsTransientOutput(t *testing.T) {
+	cases := map[string]struct {
+		output   string
+		expected bool
+	}{
+		"connection reset": {
+			output:   "error: RPC failed; curl 56 Recv failure: Connection reset by peer\nfatal: early EOF",
+			expected: true,
+		},
+		"host can't be resolved": {
+			output:   "fatal: unable to access 'https://github.com/hashicorp/terraform-provider-google/': Could not resolve host: github.com",
+			expected: true,
+		},
+		"ssh connection timed out": {
+			output:   "ssh: connect to host github.com port 22: Connection timed out\nfatal: Could not read from remote repository.",
+			expected: true,
+		},
+		"server error": {
+			output:   "fatal: unable to access 'https://github.com/hashicorp/terraform-provider-google/': The requested URL returned error: 502",
+			expected: true,
+		},
+		"changelog-gen server error": {
+			output:   "Error: GET https://api.github.com/repos/hashicorp/terraform-provider-google/pulls/1234: 502 Bad Gateway []",
+			expected: true,
+		},
+		"branch already exists": {
+			output:   "fatal: a branch named 'release-6.6.0' already exists",
+			expected: false,
+		},
+		"push rejected": {
+			output:   " ! [rejected]        release-6.6.0 -> release-6.6.0 (non-fast-forward)\nerror: failed to push some refs",
+			expected: false,
+		},
+		"authentication failed": {
+			output:   "fatal: Authentication failed for 'https://github.com/hashicorp/terraform-provider-google.git/'",
+			expected: false,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			if got := IsTransientOutput(tc.output); got != tc.expected {
+				t.Fatalf("wanted %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
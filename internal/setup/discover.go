@@ -0,0 +1,95 @@
+package setup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+)
+
+// githubRemoteRE matches the owner and repository in GitHub remote URLs, e.g.
+// git@github.com:hashicorp/terraform-provider-google.git or https://github.com/hashicorp/terraform-provider-google
+var githubRemoteRE = regexp.MustCompile(`github\.com[:/]([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// SearchRoots returns the directories that are searched for clones of the repositories, in order of preference:
+// $GOPATH/src/github.com/*, ~/go/src, ~/go/src/github.com/*, ~/src and ~/src/github.com/*
+func SearchRoots(home, gopath string) []string {
+	var patterns []string
+	for _, p := range filepath.SplitList(gopath) {
+		patterns = append(patterns, filepath.Join(p, "src", "github.com", "*"))
+	}
+	if home != "" {
+		patterns = append(patterns,
+			filepath.Join(home, "go", "src"),
+			filepath.Join(home, "go", "src", "github.com", "*"),
+			filepath.Join(home, "src"),
+			filepath.Join(home, "src", "github.com", "*"),
+		)
+	}
+
+	seen := map[string]bool{}
+	var roots []string
+	for _, p := range patterns {
+		matches, _ := filepath.Glob(p)
+		for _, m := range matches {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			roots = append(roots, m)
+		}
+	}
+	return roots
+}
+
+// FindClones returns the paths of git repositories called repoName directly inside any of the roots
+func FindClones(roots []string, repoName string) []string {
+	var clones []string
+	for _, root := range roots {
+		p := filepath.Join(root, repoName)
+		if _, err := os.Stat(filepath.Join(p, ".git")); err == nil {
+			clones = append(clones, p)
+		}
+	}
+	return clones
+}
+
+// ParseGitHubRemote returns the owner and repository from a GitHub remote URL
+func ParseGitHubRemote(url string) (string, string, bool) {
+	matches := githubRemoteRE.FindStringSubmatch(url)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+// FindRemote returns the name of the remote in the repository at dir that points at github.com/<owner>/<repo>
+func FindRemote(dir, owner, repo string) (string, error) {
+	gi := git.GitInteract{Dir: dir}
+	remotes, cmd, err := gi.GetRemotes()
+	if err != nil {
+		return "", errors.New(cmd.ErrorDescription("error when listing remotes"))
+	}
+	return matchRemote(remotes, owner, repo), nil
+}
+
+func matchRemote(remotes map[string]string, owner, repo string) string {
+	// Sort names so the result is stable if several remotes point at the same repository
+	names := make([]string, 0, len(remotes))
+	for name := range remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		o, r, ok := ParseGitHubRemote(remotes[name])
+		if ok && strings.EqualFold(o, owner) && strings.EqualFold(r, repo) {
+			return name
+		}
+	}
+	return ""
+}
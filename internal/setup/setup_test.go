@@ -0,0 +1,140 @@
+package setup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git/gittest"
+)
+
+func TestFindClones(t *testing.T) {
+	home := t.TempDir()
+	gopath := t.TempDir()
+
+	gittest.NewRepo(t, filepath.Join(gopath, "src", "github.com", "hashicorp", "terraform-provider-google"), nil)
+	gittest.NewRepo(t, filepath.Join(home, "src", "terraform-provider-google"), nil)
+	// Directories that aren't git repositories are ignored
+	if err := os.MkdirAll(filepath.Join(home, "go", "src", "terraform-provider-google"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := FindClones(SearchRoots(home, gopath), "terraform-provider-google")
+	want := []string{
+		filepath.Join(gopath, "src", "github.com", "hashicorp", "terraform-provider-google"),
+		filepath.Join(home, "src", "terraform-provider-google"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestParseGitHubRemote(t *testing.T) {
+	cases := map[string]struct {
+		url           string
+		expectedOwner string
+		expectedRepo  string
+		expectedOk    bool
+	}{
+		"SSH": {
+			url:           "git@github.com:hashicorp/terraform-provider-google.git",
+			expectedOwner: "hashicorp",
+			expectedRepo:  "terraform-provider-google",
+			expectedOk:    true,
+		},
+		"HTTPS": {
+			url:           "https://github.com/hashicorp/terraform-provider-google-beta",
+			expectedOwner: "hashicorp",
+			expectedRepo:  "terraform-provider-google-beta",
+			expectedOk:    true,
+		},
+		"HTTPS with .git suffix": {
+			url:           "https://github.com/SarahFrench/terraform-provider-google.git",
+			expectedOwner: "SarahFrench",
+			expectedRepo:  "terraform-provider-google",
+			expectedOk:    true,
+		},
+		"not GitHub": {
+			url: "https://gitlab.com/hashicorp/terraform-provider-google.git",
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			owner, repo, ok := ParseGitHubRemote(tc.url)
+			if owner != tc.expectedOwner || repo != tc.expectedRepo || ok != tc.expectedOk {
+				t.Fatalf("wanted %s, %s, %v, got %s, %s, %v", tc.expectedOwner, tc.expectedRepo, tc.expectedOk, owner, repo, ok)
+			}
+		})
+	}
+}
+
+func TestWizard_Run(t *testing.T) {
+	home := t.TempDir()
+	gaPath := filepath.Join(home, "go", "src", "github.com", "me", "terraform-provider-google")
+	betaPath := filepath.Join(home, "go", "src", "github.com", "me", "terraform-provider-google-beta")
+	gittest.NewRepo(t, gaPath, map[string]string{
+		"origin":   "git@github.com:me/terraform-provider-google.git",
+		"upstream": "git@github.com:hashicorp/terraform-provider-google.git",
+	})
+	gittest.NewRepo(t, betaPath, map[string]string{
+		"origin":   "git@github.com:me/terraform-provider-google-beta.git",
+		"upstream": "https://github.com/hashicorp/terraform-provider-google-beta",
+	})
+
+	// Accept the default owner, skip magic-modules, accept the found clones and remote, and read the token from a file
+	stdin := bytes.Buffer{}
+	stdin.Write([]byte("\n\n\n\n2\n~/.config/tpg-cli/token\n"))
+
+	w := NewWizard(home, "")
+	w.handler.SetReader(&stdin)
+
+	c, err := w.Run()
+	if err != nil {
+		t.Fatalf("unexpected error(s) encountered: %v", err)
+	}
+
+	if c.RemoteOwner != "hashicorp" {
+		t.Fatalf("unexpected value of RemoteOwner, want hashicorp, got %s", c.RemoteOwner)
+	}
+	if c.MagicModulesPath != "" {
+		t.Fatalf("unexpected value of MagicModulesPath, want empty, got %s", c.MagicModulesPath)
+	}
+	if c.GooglePath != gaPath {
+		t.Fatalf("unexpected value of GooglePath, want %s, got %s", gaPath, c.GooglePath)
+	}
+	if c.GoogleBetaPath != betaPath {
+		t.Fatalf("unexpected value of GoogleBetaPath, want %s, got %s", betaPath, c.GoogleBetaPath)
+	}
+	if c.Remote != "upstream" {
+		t.Fatalf("unexpected value of Remote, want upstream, got %s", c.Remote)
+	}
+	if c.GitHubToken != "" {
+		t.Fatalf("expected the token not to be written to the config, got %s", c.GitHubToken)
+	}
+	if tokenFile := filepath.Join(home, ".config", "tpg-cli", "token"); c.GitHubTokenFile != tokenFile {
+		t.Fatalf("unexpected value of GitHubTokenFile, want %s, got %s", tokenFile, c.GitHubTokenFile)
+	}
+}
+
+func TestWizard_choosePath_asksAgain(t *testing.T) {
+	home := t.TempDir()
+	gaPath := gittest.NewRepo(t, filepath.Join(home, "go", "src", "terraform-provider-google"), nil)
+
+	// 2 isn't one of the listed clones, so the question is asked again
+	stdin := bytes.Buffer{}
+	stdin.Write([]byte("2\n1\n"))
+
+	w := NewWizard(home, "")
+	w.handler.SetReader(&stdin)
+
+	got, err := w.choosePath("terraform-provider-google", false)
+	if err != nil {
+		t.Fatalf("unexpected error(s) encountered: %v", err)
+	}
+	if got != gaPath {
+		t.Fatalf("wanted %s, got %s", gaPath, got)
+	}
+}
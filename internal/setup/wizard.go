@@ -0,0 +1,234 @@
+package setup
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/token"
+)
+
+// Wizard interactively collects the values needed to create a config file
+type Wizard struct {
+	// handler reads answers and asks yes/no questions, the same way as when making a release
+	handler input.Handler
+
+	// roots are the directories searched for clones of the repositories
+	roots []string
+	home  string
+}
+
+func NewWizard(home, gopath string) Wizard {
+	return Wizard{
+		handler: input.NewHandler(&input.Input{}, nil),
+		roots:   SearchRoots(home, gopath),
+		home:    home,
+	}
+}
+
+// Run asks the user questions to build a config, suggesting answers using the clones and remotes
+// that can be found on their machine. The returned config has not been validated.
+func (w *Wizard) Run() (*config.Config, error) {
+	c := &config.Config{}
+
+	owner, err := w.ask("Which GitHub user or organisation owns the provider repositories you make releases for?", "hashicorp")
+	if err != nil {
+		return nil, err
+	}
+	c.RemoteOwner = owner
+
+	c.MagicModulesPath, err = w.choosePath(github.MAGIC_MODULES_REPO_NAME, true)
+	if err != nil {
+		return nil, err
+	}
+	c.GooglePath, err = w.choosePath(config.GA_REPO_NAME, false)
+	if err != nil {
+		return nil, err
+	}
+	c.GoogleBetaPath, err = w.choosePath(config.BETA_REPO_NAME, false)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Remote, err = w.chooseRemote(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.chooseTokenSource(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Confirm asks the user a yes/no question and returns whether they answered yes
+func (w *Wizard) Confirm(question string) (bool, error) {
+	return w.handler.Confirm(question)
+}
+
+// choosePath asks the user for the path to their clone of a repository, offering any clones found in the search roots
+func (w *Wizard) choosePath(repoName string, optional bool) (string, error) {
+	fmt.Println()
+	clones := FindClones(w.roots, repoName)
+
+	if len(clones) == 0 {
+		question := fmt.Sprintf("Couldn't find a clone of %s. Enter the path to your clone:", repoName)
+		if optional {
+			question = fmt.Sprintf("Couldn't find a clone of %s. Enter the path to your clone, or leave empty to skip:", repoName)
+		}
+		for {
+			p, err := w.ask(question, "")
+			if err != nil {
+				return "", err
+			}
+			if p != "" || optional {
+				return w.expandPath(p), nil
+			}
+		}
+	}
+
+	fmt.Printf("Found these clones of %s:\n", repoName)
+	for i, clone := range clones {
+		fmt.Printf("\t%d) %s\n", i+1, clone)
+	}
+	for {
+		in, err := w.ask("Enter a number to choose a clone, or enter a different path:", "1")
+		if err != nil {
+			return "", err
+		}
+		n, err := strconv.Atoi(in)
+		if err != nil {
+			return w.expandPath(in), nil
+		}
+		if n >= 1 && n <= len(clones) {
+			return clones[n-1], nil
+		}
+		fmt.Printf("%d is not one of the listed clones, try again\n", n)
+	}
+}
+
+// chooseRemote finds the remote in the provider clones that points at the official repositories.
+// The config has a single remote name, so the name needs to be the same in both clones.
+func (w *Wizard) chooseRemote(c *config.Config) (string, error) {
+	fmt.Println()
+
+	gaRemote, err := FindRemote(c.GooglePath, c.RemoteOwner, config.GA_REPO_NAME)
+	if err != nil {
+		return "", err
+	}
+	betaRemote, err := FindRemote(c.GoogleBetaPath, c.RemoteOwner, config.BETA_REPO_NAME)
+	if err != nil {
+		return "", err
+	}
+
+	if gaRemote != "" && gaRemote == betaRemote {
+		fmt.Printf("Found remote %q pointing at the %s repositories in both provider clones\n", gaRemote, c.RemoteOwner)
+		return gaRemote, nil
+	}
+
+	describe := func(repoName, remote string) {
+		if remote == "" {
+			fmt.Printf("\tNo remote in your %s clone points at github.com/%s/%s\n", repoName, c.RemoteOwner, repoName)
+			return
+		}
+		fmt.Printf("\tRemote %q in your %s clone points at github.com/%s/%s\n", remote, repoName, c.RemoteOwner, repoName)
+	}
+	fmt.Println("The provider clones need a remote with the same name that points at the official repositories:")
+	describe(config.GA_REPO_NAME, gaRemote)
+	describe(config.BETA_REPO_NAME, betaRemote)
+	fmt.Println("Use `git remote add` or `git remote rename` to fix this before making a release.")
+
+	suggestion := gaRemote
+	if suggestion == "" {
+		suggestion = betaRemote
+	}
+	for {
+		remote, err := w.ask("Enter the name of the remote to use:", suggestion)
+		if err != nil {
+			return "", err
+		}
+		if remote != "" {
+			return remote, nil
+		}
+	}
+}
+
+// chooseTokenSource asks how the CLI should get the user's GitHub token. The token itself is never asked for, so that
+// it isn't written to the config file in plain text.
+func (w *Wizard) chooseTokenSource(c *config.Config) error {
+	fmt.Println()
+	fmt.Println("The CLI needs a GitHub personal access token with no permissions. How should it get the token?")
+	fmt.Println("\t1) From the GitHub CLI, after logging in with `gh auth login`")
+	fmt.Println("\t2) From a file that only you can read, e.g. ~/.config/tpg-cli/token")
+	fmt.Println("\t3) From a command that prints the token, e.g. a password manager's CLI")
+	fmt.Println("\t4) I'll set it up later")
+
+	suggestion := "4"
+	if _, err := exec.LookPath(token.GH_CLI_EXECUTABLE); err == nil {
+		suggestion = "1"
+	}
+	for {
+		in, err := w.ask("Enter a number:", suggestion)
+		if err != nil {
+			return err
+		}
+		switch in {
+		case "1", "4":
+			return nil
+		case "2":
+			p, err := w.ask("Enter the path to the file:", "")
+			if err != nil {
+				return err
+			}
+			if p != "" {
+				c.GitHubTokenFile = w.expandPath(p)
+				fmt.Printf("Make sure only you can read the file, e.g. with `chmod 600 %s`\n", c.GitHubTokenFile)
+				return nil
+			}
+		case "3":
+			command, err := w.ask("Enter the command:", "")
+			if err != nil {
+				return err
+			}
+			if command != "" {
+				c.GitHubTokenCommand = command
+				return nil
+			}
+		default:
+			fmt.Printf("%s is not one of the listed options, try again\n", in)
+		}
+	}
+}
+
+// ask prints a question and returns the user's answer, or the default value if they don't enter anything
+func (w *Wizard) ask(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]\n", question, defaultValue)
+	} else {
+		fmt.Println(question)
+	}
+
+	in, err := w.handler.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	in = strings.TrimSpace(in)
+	if in == "" {
+		return defaultValue, nil
+	}
+	return in, nil
+}
+
+// expandPath replaces a leading ~ with the user's home directory
+func (w *Wizard) expandPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return filepath.Join(w.home, strings.TrimPrefix(p, "~"))
+	}
+	return p
+}
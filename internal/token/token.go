@@ -0,0 +1,100 @@
+package token
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
+)
+
+var GH_CLI_EXECUTABLE = "gh"
+
+// Resolve returns a GitHub token and a description of where it came from, using the first of:
+//   - the -gh_token flag
+//   - githubToken in config (or the TPG_CLI_GITHUB_TOKEN environment variable)
+//   - the file at githubTokenFile in config, which must only be accessible by the user
+//   - the output of the githubTokenCommand credential helper in config
+//   - the token stored by the GitHub CLI, via `gh auth token`
+//
+// The token is registered with the secrets package so it is redacted from output.
+func Resolve(flagValue string, c *config.Config) (string, string, error) {
+	token, source, err := resolve(flagValue, c)
+	if err != nil {
+		return "", "", err
+	}
+	secrets.Register(token)
+	return token, source, nil
+}
+
+func resolve(flagValue string, c *config.Config) (string, string, error) {
+	if flagValue != "" {
+		return flagValue, "the -gh_token flag", nil
+	}
+	if c.GitHubToken != "" {
+		return c.GitHubToken, "githubToken in config", nil
+	}
+	if c.GitHubTokenFile != "" {
+		t, err := readTokenFile(c.GitHubTokenFile)
+		if err != nil {
+			return "", "", err
+		}
+		return t, fmt.Sprintf("the file %s", c.GitHubTokenFile), nil
+	}
+	if c.GitHubTokenCommand != "" {
+		t, err := runCommand("sh", "-c", c.GitHubTokenCommand)
+		if err != nil {
+			return "", "", fmt.Errorf("error getting GitHub token from githubTokenCommand: %w", err)
+		}
+		return t, "githubTokenCommand in config", nil
+	}
+	if _, err := exec.LookPath(GH_CLI_EXECUTABLE); err == nil {
+		if t, err := runCommand(GH_CLI_EXECUTABLE, "auth", "token", "--hostname", "github.com"); err == nil {
+			return t, "the GitHub CLI's stored credentials", nil
+		}
+	}
+
+	return "", "", errors.New("no GitHub token provided: set githubTokenFile or githubTokenCommand in your config file, log in with the GitHub CLI (gh auth login), or supply using a -gh_token flag")
+}
+
+// readTokenFile reads a token from a file, refusing to use files that other users can access
+func readTokenFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening githubTokenFile: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return "", fmt.Errorf("githubTokenFile %s has permissions %#o, it should only be accessible by you: run `chmod 600 %s`", path, perm, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading githubTokenFile: %w", err)
+	}
+	t := strings.TrimSpace(string(data))
+	if t == "" {
+		return "", fmt.Errorf("githubTokenFile %s is empty", path)
+	}
+	return t, nil
+}
+
+func runCommand(name string, args ...string) (string, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	t := strings.TrimSpace(stdout.String())
+	if t == "" {
+		return "", fmt.Errorf("`%s` returned an empty token", cmd.String())
+	}
+	return t, nil
+}
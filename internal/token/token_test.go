@@ -0,0 +1,82 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+)
+
+func TestResolve(t *testing.T) {
+	// Make sure tokens stored by a real GitHub CLI aren't used
+	ghCLI := GH_CLI_EXECUTABLE
+	GH_CLI_EXECUTABLE = "gh-does-not-exist"
+	t.Cleanup(func() { GH_CLI_EXECUTABLE = ghCLI })
+
+	dir := t.TempDir()
+	privateFile := filepath.Join(dir, "private-token")
+	if err := os.WriteFile(privateFile, []byte("file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	publicFile := filepath.Join(dir, "public-token")
+	if err := os.WriteFile(publicFile, []byte("file-token\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		flagValue     string
+		config        *config.Config
+		expectedToken string
+		expectError   bool
+	}{
+		"flag takes precedence over config": {
+			flagValue:     "flag-token",
+			config:        &config.Config{GitHubToken: "config-token"},
+			expectedToken: "flag-token",
+		},
+		"token in config": {
+			config:        &config.Config{GitHubToken: "config-token", GitHubTokenFile: privateFile},
+			expectedToken: "config-token",
+		},
+		"token file": {
+			config:        &config.Config{GitHubTokenFile: privateFile, GitHubTokenCommand: "echo command-token"},
+			expectedToken: "file-token",
+		},
+		"token file readable by other users": {
+			config:      &config.Config{GitHubTokenFile: publicFile},
+			expectError: true,
+		},
+		"missing token file": {
+			config:      &config.Config{GitHubTokenFile: filepath.Join(dir, "doesnt-exist")},
+			expectError: true,
+		},
+		"credential helper command": {
+			config:        &config.Config{GitHubTokenCommand: "echo command-token"},
+			expectedToken: "command-token",
+		},
+		"failing credential helper command": {
+			config:      &config.Config{GitHubTokenCommand: "exit 1"},
+			expectError: true,
+		},
+		"no token": {
+			config:      &config.Config{},
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			token, _, err := Resolve(tc.flagValue, tc.config)
+			if err != nil && !tc.expectError {
+				t.Fatalf("unexpected error(s) encountered: %v", err)
+			}
+			if err == nil && tc.expectError {
+				t.Fatal("expected error but got none")
+			}
+			if token != tc.expectedToken {
+				t.Fatalf("wanted %q, got %q", tc.expectedToken, token)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+)
+
+// MAX_LOG_LINES is the number of lines of log output kept for display
+var MAX_LOG_LINES = 500
+
+// MIN_LOG_LINES is the fewest lines of log output shown, even when the terminal is too short to fit everything else
+var MIN_LOG_LINES = 3
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	doneStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	failedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	pendingStyle  = lipgloss.NewStyle().Faint(true)
+	logStyle      = lipgloss.NewStyle().Faint(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+type status int
+
+const (
+	statusPending status = iota
+	statusRunning
+	statusDone
+	statusFailed
+)
+
+type stepMsg struct {
+	index  int
+	status status
+	err    error
+}
+
+type logMsg string
+
+// outputMsg is raw output, which can contain partial lines and carriage returns, e.g. git's progress
+type outputMsg string
+
+type promptMsg input.Prompt
+
+type previewMsg struct {
+	title string
+	body  string
+}
+
+type model struct {
+	title    string
+	steps    []Step
+	statuses []status
+	// finished is true once every step is done, or one has failed
+	finished bool
+	// err is the error from the step that failed
+	err error
+
+	spinner spinner.Model
+	logs    []string
+	// lineOpen is true if the last line of the logs hasn't been ended by a newline yet
+	lineOpen bool
+	// overwrite is true if the next output replaces the last line of the logs, after a carriage return
+	overwrite bool
+
+	// prompt is the question being asked, or nil if there isn't one
+	prompt   *input.Prompt
+	cursor   int
+	showHelp bool
+	answer   textinput.Model
+	answers  chan<- string
+
+	previewTitle string
+	preview      *viewport.Model
+
+	width  int
+	height int
+}
+
+func newModel(title string, steps []Step, answers chan<- string) model {
+	answer := textinput.New()
+	answer.Prompt = "> "
+	answer.Placeholder = "type an answer, or press enter to choose the highlighted option"
+
+	return model{
+		title:    title,
+		steps:    steps,
+		statuses: make([]status, len(steps)),
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+		answer:   answer,
+		answers:  answers,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case stepMsg:
+		m.statuses[msg.index] = msg.status
+		switch {
+		case msg.status == statusFailed:
+			m.err = msg.err
+			m.finished = true
+		case msg.status == statusDone && msg.index == len(m.steps)-1:
+			m.finished = true
+		}
+		return m, nil
+
+	case logMsg:
+		m.addLogs(string(msg))
+		return m, nil
+
+	case outputMsg:
+		m.addOutput(string(msg))
+		return m, nil
+
+	case promptMsg:
+		p := input.Prompt(msg)
+		m.prompt = &p
+		m.cursor = 0
+		if p.Default >= 0 && p.Default < len(p.Options) {
+			m.cursor = p.Default
+		}
+		m.showHelp = false
+		m.answer.Reset()
+		return m, m.answer.Focus()
+
+	case previewMsg:
+		vp := viewport.New(m.width, 0)
+		vp.SetContent(msg.body)
+		m.previewTitle = msg.title
+		m.preview = &vp
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch {
+		case m.prompt != nil:
+			return m.updatePrompt(msg)
+		case m.finished && (msg.String() == "q" || msg.String() == "esc"):
+			return m, tea.Quit
+		case m.preview != nil:
+			vp, cmd := m.preview.Update(msg)
+			m.preview = &vp
+			return m, cmd
+		case m.finished && msg.String() == "enter":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// updatePrompt handles key presses while a question is being asked. The arrow keys choose an option, and anything
+// typed is used as the answer instead of the chosen option.
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.cursor < len(m.prompt.Options)-1 {
+			m.cursor++
+		}
+		return m, nil
+	case "enter":
+		answer := strings.TrimSpace(m.answer.Value())
+		if answer == input.HELP_ANSWER {
+			m.showHelp = !m.showHelp
+			m.answer.Reset()
+			return m, nil
+		}
+		if answer == "" && len(m.prompt.Options) > 0 {
+			answer = m.prompt.Options[m.cursor].Answer
+		}
+		m.addLogs(fmt.Sprintf("%s %s", m.prompt.Question, answer))
+		m.prompt = nil
+		m.answer.Blur()
+		m.answers <- answer
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.answer, cmd = m.answer.Update(msg)
+	return m, cmd
+}
+
+func (m *model) addLogs(s string) {
+	m.lineOpen, m.overwrite = false, false
+	m.logs = append(m.logs, strings.Split(s, "\n")...)
+	m.trimLogs()
+}
+
+// addOutput adds raw output to the logs. A carriage return starts the line again, like in a terminal, so that progress
+// updates replace each other instead of filling the logs.
+func (m *model) addOutput(s string) {
+	for s != "" {
+		text, sep := s, byte(0)
+		if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+			text, sep, s = s[:i], s[i], s[i+1:]
+		} else {
+			s = ""
+		}
+
+		switch {
+		case !m.lineOpen:
+			m.logs = append(m.logs, text)
+			m.lineOpen = true
+		case m.overwrite && text != "":
+			m.logs[len(m.logs)-1] = text
+			m.overwrite = false
+		default:
+			m.logs[len(m.logs)-1] += text
+		}
+
+		switch sep {
+		case '\n':
+			m.lineOpen, m.overwrite = false, false
+		case '\r':
+			m.overwrite = true
+		}
+	}
+	m.trimLogs()
+}
+
+func (m *model) trimLogs() {
+	if len(m.logs) > MAX_LOG_LINES {
+		m.logs = m.logs[len(m.logs)-MAX_LOG_LINES:]
+	}
+}
+
+func (m model) View() string {
+	top := []string{titleStyle.Render(m.title), ""}
+	for i, s := range m.steps {
+		switch m.statuses[i] {
+		case statusPending:
+			top = append(top, pendingStyle.Render("· "+s.Name))
+		case statusRunning:
+			top = append(top, m.spinner.View()+s.Name)
+		case statusDone:
+			top = append(top, doneStyle.Render("✓ "+s.Name))
+		case statusFailed:
+			top = append(top, failedStyle.Render("✗ "+s.Name))
+		}
+	}
+	top = append(top, "")
+
+	bottom := m.bottomView()
+	remaining := m.height - len(top) - len(bottom)
+
+	var middle []string
+	if m.preview != nil && m.prompt == nil {
+		m.preview.Width = m.width
+		m.preview.Height = max(remaining-1, MIN_LOG_LINES)
+		middle = append(middle, titleStyle.Render(m.previewTitle), m.preview.View())
+	} else {
+		n := max(remaining, MIN_LOG_LINES)
+		logs := m.logs
+		if len(logs) > n {
+			logs = logs[len(logs)-n:]
+		}
+		for _, l := range logs {
+			middle = append(middle, logStyle.Render(m.truncate(l)))
+		}
+	}
+
+	lines := append(top, middle...)
+	lines = append(lines, bottom...)
+	return strings.Join(lines, "\n")
+}
+
+// bottomView shows the question being asked, or the error from a failed step, and which keys can be used
+func (m model) bottomView() []string {
+	var lines []string
+	if p := m.prompt; p != nil {
+		lines = append(lines, "", titleStyle.Render(p.Question))
+		if p.Header != "" {
+			lines = append(lines, "  "+m.truncate(p.Header))
+		}
+		for i, o := range p.Options {
+			if i == m.cursor {
+				lines = append(lines, selectedStyle.Render(m.truncate("> "+o.Label)))
+			} else {
+				lines = append(lines, m.truncate("  "+o.Label))
+			}
+		}
+		lines = append(lines, m.answer.View())
+		if p.Invalid != nil {
+			lines = append(lines, failedStyle.Render(fmt.Sprintf("Invalid answer: %s", p.Invalid)))
+		}
+		if m.showHelp {
+			lines = append(lines, helpStyle.Render(strings.TrimRight(p.Help, "\n")))
+		}
+		keys := "enter: answer • ?+enter: help • ctrl+c: quit"
+		if len(p.Options) > 0 {
+			keys = "↑/↓: choose • " + keys
+		}
+		return append(lines, "", pendingStyle.Render(keys))
+	}
+
+	if m.err != nil {
+		lines = append(lines, "", failedStyle.Render(m.err.Error()))
+	}
+	switch {
+	case m.finished && m.preview != nil:
+		lines = append(lines, "", pendingStyle.Render("↑/↓: scroll • q: quit"))
+	case m.finished:
+		lines = append(lines, "", pendingStyle.Render("enter/q: quit"))
+	default:
+		lines = append(lines, "", pendingStyle.Render("ctrl+c: quit"))
+	}
+	return lines
+}
+
+// truncate shortens a line to the width of the terminal, so it doesn't wrap and push the checklist off the screen
+func (m model) truncate(line string) string {
+	r := []rune(line)
+	if m.width <= 0 || len(r) <= m.width {
+		return line
+	}
+	return string(r[:m.width-1]) + "…"
+}
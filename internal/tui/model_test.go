@@ -0,0 +1,193 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+)
+
+var testSteps = []Step{
+	{Name: "Collect inputs"},
+	{Name: "Cut release branch"},
+}
+
+func update(t *testing.T, m model, msgs ...tea.Msg) model {
+	t.Helper()
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	return m
+}
+
+func keys(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+func Test_model_steps(t *testing.T) {
+	cases := map[string]struct {
+		msgs             []tea.Msg
+		expectedFinished bool
+		expectedView     []string
+	}{
+		"first step running": {
+			msgs:         []tea.Msg{stepMsg{index: 0, status: statusRunning}},
+			expectedView: []string{"Collect inputs", "· Cut release branch"},
+		},
+		"all steps done": {
+			msgs: []tea.Msg{
+				stepMsg{index: 0, status: statusDone},
+				stepMsg{index: 1, status: statusDone},
+			},
+			expectedFinished: true,
+			expectedView:     []string{"✓ Collect inputs", "✓ Cut release branch"},
+		},
+		"step failed": {
+			msgs: []tea.Msg{
+				stepMsg{index: 0, status: statusDone},
+				stepMsg{index: 1, status: statusFailed, err: errors.New("error when creating a new release branch")},
+			},
+			expectedFinished: true,
+			expectedView:     []string{"✗ Cut release branch", "error when creating a new release branch"},
+		},
+		"log output": {
+			msgs:         []tea.Msg{logMsg("Starting to create and push new release branch\nRelease branch release-6.6.0 was created and pushed")},
+			expectedView: []string{"Starting to create", "release-6.6.0 was created"},
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			m := update(t, newModel("Release", testSteps, nil), tc.msgs...)
+			if m.finished != tc.expectedFinished {
+				t.Fatalf("wanted finished to be %t, got %t", tc.expectedFinished, m.finished)
+			}
+			view := m.View()
+			for _, s := range tc.expectedView {
+				if !strings.Contains(view, s) {
+					t.Fatalf("expected view to contain %q, got:\n%s", s, view)
+				}
+			}
+		})
+	}
+}
+
+func Test_model_prompt(t *testing.T) {
+	prompt := input.Prompt{
+		Question: "What provider do you want to make a release for (ga/beta)?",
+		Help:     "Enter the name of the release target",
+		Options:  []input.Option{{Label: "ga", Answer: "ga"}, {Label: "beta", Answer: "beta"}},
+		Default:  -1,
+	}
+
+	cases := map[string]struct {
+		prompt         input.Prompt
+		msgs           []tea.Msg
+		expectedAnswer string
+		expectedHelp   bool
+	}{
+		"choosing the highlighted option": {
+			prompt:         prompt,
+			msgs:           []tea.Msg{tea.KeyMsg{Type: tea.KeyEnter}},
+			expectedAnswer: "ga",
+		},
+		"moving to another option": {
+			prompt:         prompt,
+			msgs:           []tea.Msg{tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter}},
+			expectedAnswer: "beta",
+		},
+		"default option is highlighted first": {
+			prompt:         input.Prompt{Question: prompt.Question, Options: prompt.Options, Default: 1},
+			msgs:           []tea.Msg{tea.KeyMsg{Type: tea.KeyEnter}},
+			expectedAnswer: "beta",
+		},
+		"typing an answer instead of choosing an option": {
+			prompt:         prompt,
+			msgs:           append(keys("tgc"), tea.KeyMsg{Type: tea.KeyEnter}),
+			expectedAnswer: "tgc",
+		},
+		"asking for help": {
+			prompt:       prompt,
+			msgs:         append(keys(input.HELP_ANSWER), tea.KeyMsg{Type: tea.KeyEnter}),
+			expectedHelp: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			answers := make(chan string, 1)
+			m := update(t, newModel("Release", testSteps, answers), promptMsg(tc.prompt))
+			m = update(t, m, tc.msgs...)
+
+			if tc.expectedHelp {
+				if m.prompt == nil || !m.showHelp {
+					t.Fatal("expected help to be shown while the question is still being asked")
+				}
+				if !strings.Contains(m.View(), tc.prompt.Help) {
+					t.Fatalf("expected view to contain help, got:\n%s", m.View())
+				}
+				return
+			}
+
+			select {
+			case answer := <-answers:
+				if answer != tc.expectedAnswer {
+					t.Fatalf("wanted answer %q, got %q", tc.expectedAnswer, answer)
+				}
+			default:
+				t.Fatal("expected an answer but got none")
+			}
+			if m.prompt != nil {
+				t.Fatal("expected the question to be removed after it was answered")
+			}
+		})
+	}
+}
+
+func Test_model_addOutput(t *testing.T) {
+	cases := map[string]struct {
+		output       []string
+		expectedLogs []string
+	}{
+		"log lines": {
+			output:       []string{"Starting to create and push new release branch\n", "Creating CHANGELOG entry\n"},
+			expectedLogs: []string{"Starting to create and push new release branch", "Creating CHANGELOG entry"},
+		},
+		"progress replaces itself": {
+			output:       []string{"Receiving objects:  10% (1/10)\r", "Receiving objects: 100% (10/10)\r", "Receiving objects: 100% (10/10), done.\n"},
+			expectedLogs: []string{"Receiving objects: 100% (10/10), done."},
+		},
+		"line written in parts": {
+			output:       []string{"From github.com:hashicorp/", "terraform-provider-google\n"},
+			expectedLogs: []string{"From github.com:hashicorp/terraform-provider-google"},
+		},
+		"progress that isn't finished": {
+			output:       []string{"Counting objects: 50% (5/10)\r"},
+			expectedLogs: []string{"Counting objects: 50% (5/10)"},
+		},
+		"carriage return before a newline": {
+			output:       []string{"Compressing objects: 100% (4/4)\r\n", "Writing objects: 100% (5/5)\n"},
+			expectedLogs: []string{"Compressing objects: 100% (4/4)", "Writing objects: 100% (5/5)"},
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			m := newModel("Release", testSteps, nil)
+			for _, o := range tc.output {
+				m = update(t, m, outputMsg(o))
+			}
+			if strings.Join(m.logs, "\n") != strings.Join(tc.expectedLogs, "\n") {
+				t.Fatalf("wanted logs %q, got %q", tc.expectedLogs, m.logs)
+			}
+		})
+	}
+}
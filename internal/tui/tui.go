@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+)
+
+// ErrCancelled is returned when the user quits the UI before all the steps have finished
+var ErrCancelled = failure.ErrCancelled
+
+// Step is one stage of a workflow, shown as an item in the checklist
+type Step struct {
+	Name string
+	Run  func() error
+}
+
+// UI is a full-screen terminal UI that runs steps in order, showing their progress as a checklist alongside log
+// output. It implements input.Prompter, so questions are asked with selectable lists of answers.
+type UI struct {
+	program *tea.Program
+	steps   []Step
+
+	answers chan string
+	// quit is closed when the program exits, so that steps waiting for an answer stop waiting
+	quit chan struct{}
+}
+
+var _ input.Prompter = &UI{}
+
+func New(title string, steps []Step) *UI {
+	u := &UI{
+		steps:   steps,
+		answers: make(chan string, 1),
+		quit:    make(chan struct{}),
+	}
+	u.program = tea.NewProgram(newModel(title, steps, u.answers), tea.WithAltScreen())
+	return u
+}
+
+// Run shows the UI and runs each step in order until one fails, then waits for the user to quit. It returns the error
+// from the failed step, or ErrCancelled if the user quit before the steps finished.
+func (u *UI) Run() error {
+	go u.runSteps()
+
+	final, err := u.program.Run()
+	close(u.quit)
+	if err != nil {
+		return err
+	}
+
+	m := final.(model)
+	if !m.finished {
+		return ErrCancelled
+	}
+	return m.err
+}
+
+func (u *UI) runSteps() {
+	for i, s := range u.steps {
+		select {
+		case <-u.quit:
+			return
+		default:
+		}
+
+		u.program.Send(stepMsg{index: i, status: statusRunning})
+		if err := s.Run(); err != nil {
+			u.program.Send(stepMsg{index: i, status: statusFailed, err: err})
+			return
+		}
+		u.program.Send(stepMsg{index: i, status: statusDone})
+	}
+}
+
+// Quit closes the UI, e.g. when the process is terminated. Run returns ErrCancelled if the steps hadn't finished.
+func (u *UI) Quit() {
+	u.program.Quit()
+}
+
+// Ask shows the prompt beneath the checklist and waits for the user to answer it
+func (u *UI) Ask(p input.Prompt) (string, error) {
+	u.program.Send(promptMsg(p))
+	select {
+	case answer := <-u.answers:
+		return answer, nil
+	case <-u.quit:
+		return "", ErrCancelled
+	}
+}
+
+// Notify adds a message to the log output
+func (u *UI) Notify(message string) {
+	u.program.Send(logMsg(message))
+}
+
+// Write adds output to the log, so that the UI can be used as the output of a log.Logger or a git command's progress
+func (u *UI) Write(p []byte) (int, error) {
+	u.program.Send(outputMsg(p))
+	return len(p), nil
+}
+
+// Preview shows text in a scrollable view in place of the log output, e.g. a generated changelog
+func (u *UI) Preview(title, body string) {
+	u.program.Send(previewMsg{title: title, body: body})
+}
+
+// Suspend hands the terminal back while fn runs, e.g. to open a file in the user's $EDITOR
+func (u *UI) Suspend(fn func() error) error {
+	if err := u.program.ReleaseTerminal(); err != nil {
+		return err
+	}
+	fnErr := fn()
+	if err := u.program.RestoreTerminal(); err != nil {
+		return errors.Join(fnErr, err)
+	}
+	return fnErr
+}
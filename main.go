@@ -1,193 +1,360 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-isatty"
 
 	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/changelog"
 	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
 	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
 	input_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
 	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/release_version"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/tui"
 )
 
 var changelogExecutable string = "changelog-gen"
 
 func main() {
 
+	// Make sure tokens never appear in logged commands and errors
+	log.SetOutput(secrets.NewWriter(os.Stderr))
+
+	// Subcommands that are alternatives to making a release
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "changelog":
+			runChangelogCommand(os.Args[2:])
+			return
+		case "config":
+			runConfigCommand(os.Args[2:])
+			return
+		case "doctor":
+			runDoctorCommand(os.Args[2:])
+			return
+		}
+	}
+
 	// Handle inputs via flags
 	var githubToken string
 	var commitShaFlag string
 	var releaseVersionFlag string
 	var previousReleaseVersionFlag string
+	var targetFlag string
 	var gaFlag bool
 	var betaFlag bool
+	var editFlag bool
+	var configFlag string
+	var profileFlag string
+	var manifestFlag string
+	var promptAttemptsFlag int
+	var tuiFlag bool
+	var yesFlag bool
+	var nonInteractiveFlag bool
+	var resultFileFlag string
 
-	flag.StringVar(&githubToken, "gh_token", "", "Create a PAT with no permissions, see: https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token")
+	flag.StringVar(&githubToken, "gh_token", "", "Create a PAT with no permissions, see: https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token. Flag values are visible to other users in the process list, so prefer githubTokenFile or githubTokenCommand in config")
 	flag.StringVar(&commitShaFlag, "commit_sha", "", "The commit from the main branch that will be used for the release")
 	flag.StringVar(&releaseVersionFlag, "release_version", "", "The version that we're about to prepare, in format v4.XX.0")
 	flag.StringVar(&previousReleaseVersionFlag, "prev_release_version", "", "The previous version that was released, in format v4.XX.0")
-	flag.BoolVar(&gaFlag, "ga", false, "Flag to start creating a release for the GA provider")
-	flag.BoolVar(&betaFlag, "beta", false, "Flag to start creating a release for the Beta provider")
+	flag.StringVar(&targetFlag, "target", "", "Name of the release target in config to create a release for, e.g. ga, beta or another configured repository")
+	flag.BoolVar(&gaFlag, "ga", false, "Flag to start creating a release for the GA provider, shorthand for -target=ga")
+	flag.BoolVar(&betaFlag, "beta", false, "Flag to start creating a release for the Beta provider, shorthand for -target=beta")
+	flag.StringVar(&configFlag, "config", "", "Path to the config file, overriding the default locations")
+	flag.StringVar(&profileFlag, "profile", "", "Name of a profile in the config file to use, e.g. fork-testing")
+	flag.BoolVar(&editFlag, "edit", false, "Flag to open the generated changelog in $EDITOR for changes before it's printed")
+	flag.StringVar(&manifestFlag, "manifest", "", "Path to a JSON, YAML or TOML manifest describing the release. Flags take precedence over values in the manifest")
+	flag.IntVar(&promptAttemptsFlag, "prompt_attempts", input_pkg.DEFAULT_MAX_ATTEMPTS, "Number of invalid answers allowed for each interactive prompt before exiting")
+	flag.BoolVar(&tuiFlag, "tui", false, "Flag to show the release steps as a checklist in a full-screen terminal UI, with questions asked using selectable lists")
+	flag.BoolVar(&yesFlag, "yes", false, "Flag to skip confirming the release details before the release branch is created and pushed, e.g. for automation")
+	flag.BoolVar(&nonInteractiveFlag, "non_interactive", false, "Flag to fail instead of prompting for missing inputs, e.g. in CI. This is the default when stdin isn't a terminal")
+	flag.StringVar(&resultFileFlag, "result_file", "", "Path to write a JSON document describing the result of the release to, or - for stdout. Defaults to stdout when running non-interactively")
 	flag.Parse()
 
+	// The token from the flag is redacted from everything, including failures before it's used
+	secrets.Register(githubToken)
+
+	// Without a terminal to answer prompts, e.g. in a scheduled pipeline, reading answers from stdin would block forever
+	if !nonInteractiveFlag && !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		log.Print("stdin is not a terminal, so running non-interactively")
+		nonInteractiveFlag = true
+	}
+	if nonInteractiveFlag && resultFileFlag == "" {
+		resultFileFlag = "-"
+	}
+
+	// Stop running git commands when the user presses Ctrl-C or the process is terminated, so that the repository is
+	// left in a usable state and cleanup can run
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(signalCtx)
+	defer cancel()
+
+	input := input_pkg.Input{}
+	r := release{
+		ctx:           ctx,
+		input:         &input,
+		githubToken:   githubToken,
+		yes:           yesFlag,
+		editChangelog: changelog.Edit,
+		publish:       printChangelog,
+	}
+	// Failures before the release starts are written to the result too, so that automation always gets one
+	fail := func(err error) {
+		r.writeResult(resultFileFlag, err)
+		exit(err)
+	}
+
 	// Load in config
-	c, err := config.LoadConfigFromFile()
+	c, err := config.LoadConfigFromFile(configFlag, profileFlag)
 	if err != nil {
-		log.Fatal(err.Error())
+		fail(&failure.ConfigError{Err: err})
 	}
+	r.config = c
 
 	// Make sure dependencies present
 	_, err = exec.LookPath(changelogExecutable)
 	if err != nil {
-		log.Fatal("you need to have changelog-gen in your PATH to use this CLI. Ensure it is in your PATH or download it via: go install github.com/paultyng/changelog-gen@master")
+		fail(&failure.ConfigError{Err: errors.New("you need to have changelog-gen in your PATH to use this CLI. Ensure it is in your PATH or download it via: go install github.com/paultyng/changelog-gen@master")})
 	}
 
 	// Ready to collect input
-	input := input_pkg.Input{}
-	handler := input_pkg.NewHandler(&input)
-
-	// PROVIDER CHOICE
-	fmt.Println()
-	if gaFlag || betaFlag {
-		// Info provided by flags
-		fmt.Println("Provider choice set via flag:")
-		err := input.SetProviderFromFlags(gaFlag, betaFlag)
+	targets := c.GetTargets()
+	handler := input_pkg.NewHandler(&input, targets)
+	handler.SetMaxAttempts(promptAttemptsFlag)
+
+	// Each input is taken from flags, then the manifest, then TPG_CLI_* environment variables,
+	// and any that are still missing are prompted for
+	flagValues, err := input_pkg.FlagValues(targetFlag, gaFlag, betaFlag, commitShaFlag, releaseVersionFlag, previousReleaseVersionFlag)
+	if err != nil {
+		fail(&failure.InputError{Err: err})
+	}
+	resolver := input_pkg.Resolver{
+		Flags:   flagValues,
+		Env:     input_pkg.EnvValues(),
+		Handler: &handler,
+		Commits: trunkCommitFinder{ctx: ctx, timeout: c.GetGitTimeout()},
+		LatestVersion: func(t *config.Target) (string, error) {
+			rq := release_version.New(t.Owner, t.Repo)
+			latestTag, err := rq.GetLastVersionFromGitHub(ctx)
+			if err != nil {
+				return "", &failure.GitHubError{Err: err}
+			}
+			return t.VersionFromTag(latestTag)
+		},
+	}
+	if c.CommitCutoff != "" {
+		cutoff, err := config.ParseCutoff(c.CommitCutoff)
 		if err != nil {
-			log.Fatal(err.Error())
+			fail(&failure.ConfigError{Err: err})
 		}
-		fmt.Printf("\tMaking a release for %s\n", input.GetProviderRepoName())
-	} else {
-		// Need to get info via stdin
-		err = handler.PromptAndProcessProviderChoiceInput()
+		resolver.CommitCutoff = cutoff.Previous(time.Now())
+	}
+	if manifestFlag != "" {
+		m, err := input_pkg.LoadManifest(manifestFlag, targets)
 		if err != nil {
-			log.Fatal(err.Error())
+			fail(&failure.InputError{Err: err})
 		}
+		resolver.Manifest = m
+		editFlag = editFlag || m.Edit
 	}
 
-	// RELEASE VERSION CHOICE
-	if releaseVersionFlag != "" || previousReleaseVersionFlag != "" {
-		// Info provided by flags
-		log.Println("Release version infomation provided by flags:")
-		log.Printf("\tPrevious release version: %s\n", previousReleaseVersionFlag)
-		log.Printf("\tNew release version: %s\n", releaseVersionFlag)
-		err := input.SetReleaseVersions(releaseVersionFlag, previousReleaseVersionFlag)
-		if err != nil {
-			log.Fatal(err.Error())
+	if nonInteractiveFlag {
+		if err := checkNonInteractiveFlags(tuiFlag, editFlag, yesFlag); err != nil {
+			fail(&failure.InputError{Err: err})
 		}
-	} else {
+		handler.SetPrompter(input_pkg.NonInteractive{Output: os.Stderr})
+		resolver.NonInteractive = true
+	}
 
-		// Prepare info about the last release and proposed new minor release versions.
-		rq := release_version.New(c.RemoteOwner, input.GetProviderRepoName())
-		latestVersion, err := rq.GetLastVersionFromGitHub()
-		if err != nil {
-			log.Fatal(err.Error())
-		}
-		proposedNextVersion, err := release_version.NextMinorVersion(latestVersion)
-		if err != nil {
-			log.Fatal(err.Error())
+	r.resolver = &resolver
+	r.handler = &handler
+	r.edit = editFlag
+	defer r.cleanup()
+
+	if nonInteractiveFlag {
+		// Keep stdout for the JSON result, which includes the changelog
+		r.publish = func(output, url string) {
+			log.Printf("Copy the CHANGELOG in the result into : %s", url)
 		}
+	}
 
-		// Need to get info via stdin
-		handler.PromptAndProcessReleaseVersionChoiceInput(latestVersion, proposedNextVersion)
+	done := make(chan error, 1)
+	go func() {
+		if tuiFlag {
+			done <- runReleaseTUI(&r)
+		} else {
+			done <- runRelease(&r, !nonInteractiveFlag)
+		}
+	}()
+	select {
+	case err = <-done:
+	case <-signalCtx.Done():
+		// Stop handling signals, so that pressing Ctrl-C again exits straight away instead of waiting for the step
+		stop()
+		err = waitAfterInterrupt(&r, done)
 	}
+	// Stop any git command that's still running, e.g. if the user quit the terminal UI part way through a step
+	cancel()
 
-	// 'COMMIT TO CUT RELEASE ON' CHOICE
-	if commitShaFlag != "" {
-		// Info provided by flags
-		log.Printf("Release cut commit provided by flag: %s\n", commitShaFlag)
-		input.SetCommit(commitShaFlag)
-	} else {
-		// Need to get info via stdin
-		handler.PromptAndProcessCommitChoiceInput()
+	r.writeResult(resultFileFlag, err)
+	if err != nil {
+		r.restoreClone()
+		r.cleanup()
+		exit(err)
 	}
+}
 
-	// Double check inputs from above
-	if err := input.Validate(); err != nil {
-		log.Fatal(fmt.Errorf("validation error raised after collecting user inputs: %w", err))
+// exit logs the error and exits with the exit code for its class of failure, see failure.Code
+func exit(err error) {
+	log.Print(err.Error())
+	os.Exit(int(failure.Code(err)))
+}
+
+// waitAfterInterrupt waits for the release to stop after the user presses Ctrl-C or the process is terminated. Running
+// git commands are interrupted and given time to exit, but a prompt that's waiting for an answer can't be stopped, so
+// it's abandoned.
+func waitAfterInterrupt(r *release, done <-chan error) error {
+	log.Print("Interrupted, stopping the release. Press Ctrl-C again to exit straight away, without cleaning up")
+	if r.handler.Waiting() {
+		return fmt.Errorf("%w while waiting for an answer", failure.ErrCancelled)
 	}
-	if githubToken == "" && c.GitHubToken == "" {
-		log.Fatal("no GitHub token provided: either add one to your config file or supply using a -gh_token flag")
+	select {
+	case err := <-done:
+		if err == nil {
+			return nil
+		}
+		// Once the release branch is being pushed, the exit code tells wrapper scripts what to check on the remote
+		// before retrying, which matters more than the release having been cancelled
+		if code := failure.Code(err); code == failure.EXIT_GIT_PUSH || code == failure.EXIT_CHANGELOG {
+			return fmt.Errorf("interrupted: %w", err)
+		}
+		return fmt.Errorf("%w: %w", failure.ErrCancelled, err)
+	case <-time.After(git.STOP_GRACE_PERIOD + time.Second):
+		return fmt.Errorf("%w, and the running step didn't stop in time", failure.ErrCancelled)
 	}
+}
 
-	// Prepare
-	dir := c.GetProviderDirectoryPath(input.GetProviderRepoName())
-	gi := git.GitInteract{
-		Dir:             dir,
-		PreviousRelease: input.PreviousReleaseVersion,
-		Remote:          c.Remote,
+// checkNonInteractiveFlags returns an error if flags that need a user at the terminal are used when running non-interactively
+func checkNonInteractiveFlags(tui, edit, yes bool) error {
+	switch {
+	case tui:
+		return errors.New("the -tui flag can't be used when running non-interactively")
+	case edit:
+		return errors.New("the changelog can't be edited when running non-interactively, remove the -edit flag, or edit: true from the manifest")
+	case !yes:
+		return errors.New("the release details can't be confirmed when running non-interactively, use the -yes flag to skip confirming them")
 	}
+	return nil
+}
 
-	// Ensure we have checked out main
-	cmd, err := gi.Checkout("main")
-	if err != nil {
-		log.Fatal(cmd.ErrorDescription("error when checking out provided commit SHA"))
+// runRelease runs each step of the release in turn, until one fails
+func runRelease(r *release, interactive bool) error {
+	if interactive {
+		fmt.Println()
+	}
+	for _, step := range r.timeSteps(r.steps()) {
+		if err := step.Run(); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Run commands to create the release branch
-	lastReleaseCommit, cmd, err := gi.GetLastReleaseCommit()
-	if err != nil {
-		log.Fatal(cmd.ErrorDescription("error when getting last release's commit"))
+// runReleaseTUI makes the release in a full-screen terminal UI, showing the release steps as a checklist. Questions are
+// asked in the UI and log output is shown beneath the checklist.
+func runReleaseTUI(r *release) error {
+	ui := tui.New("Making a release", r.timeSteps(r.steps()))
+	r.handler.SetPrompter(ui)
+	r.editChangelog = func(output string) (string, error) {
+		var edited string
+		err := ui.Suspend(func() error {
+			var err error
+			edited, err = changelog.Edit(output)
+			return err
+		})
+		return edited, err
+	}
+	r.publish = func(output, url string) {
+		ui.Preview(fmt.Sprintf("Copy the CHANGELOG below into : %s", url), output)
 	}
 
-	log.Print("Starting to create and push new release branch")
+	// Close the UI if the process is terminated, so the terminal is restored
+	go func() {
+		<-r.ctx.Done()
+		ui.Quit()
+	}()
 
-	// git pull $REMOTE main --tags
-	cmd, err = gi.PullTagsMainBranch()
+	log.SetFlags(0)
+	log.SetOutput(secrets.NewWriter(ui))
+	err := ui.Run()
+	log.SetFlags(log.LstdFlags)
+	log.SetOutput(secrets.NewWriter(os.Stderr))
 	if err != nil {
-		log.Fatal(cmd.ErrorDescription("error when pulling tags"))
+		return err
 	}
 
-	// git checkout $COMMIT_SHA
-	cmd, err = gi.Checkout(input.CommitSha)
-	if err != nil {
-		log.Fatal(cmd.ErrorDescription("error when checking out provided commit SHA"))
-	}
+	// The UI is cleared when it exits, so leave the changelog in the terminal
+	printChangelog(r.changelog, r.changelogURL())
+	return nil
+}
+
+// trunkCommitFinder finds commits on the remote-tracking branch of a target's trunk branch, e.g. upstream/main, so
+// that the commits listed are up to date even if the local clone isn't
+type trunkCommitFinder struct {
+	ctx     context.Context
+	timeout time.Duration
+}
+
+func (f trunkCommitFinder) gitInteract(t *config.Target) git.GitInteract {
+	return git.GitInteract{Dir: t.Path, Remote: t.Remote, TrunkBranch: t.TrunkBranch, Context: f.ctx, Timeout: f.timeout, Progress: log.Writer(), Retry: retry.DEFAULT_POLICY}
+}
 
-	// git checkout -b release-$RELEASE_VERSION && git push -u $REMOTE release-$RELEASE_VERSION
-	branchName, cmd, err := gi.CreateAndPushReleaseBranch(input.ReleaseVersion)
+func (f trunkCommitFinder) RecentCommits(t *config.Target, n int) ([]git.Commit, error) {
+	gi := f.gitInteract(t)
+	cmd, err := gi.FetchTrunkBranch()
 	if err != nil {
-		log.Fatal(cmd.ErrorDescription("error when creating a new release branch"))
+		return nil, &failure.GitError{Summary: fmt.Sprintf("error when fetching %s", gi.RemoteTrunkBranch()), Command: cmd}
 	}
-
-	// This should be the same as input.CommitSha, but the release process includes running
-	// git rev-list -n 1 HEAD
-	lastCommitCurrentRelease, cmd, err := gi.GetLastCommitOfCurrentRelease(branchName)
+	commits, cmd, err := gi.GetRecentCommits(gi.RemoteTrunkBranch(), n)
 	if err != nil {
-		log.Fatal(cmd.ErrorDescription("error when getting last commit of current release"))
+		return nil, &failure.GitError{Summary: fmt.Sprintf("error when listing commits on %s", gi.RemoteTrunkBranch()), Command: cmd}
 	}
+	return commits, nil
+}
 
-	log.Printf("Release branch %s was created and pushed", branchName)
-
-	log.Println("Creating CHANGELOG entry")
-
-	token := githubToken
-	if token == "" {
-		// Flag takes precedence over config
-		token = c.GitHubToken
+func (f trunkCommitFinder) IsOnTrunk(t *config.Target, sha string) (bool, error) {
+	gi := f.gitInteract(t)
+	onTrunk, _, err := gi.IsAncestor(sha, gi.RemoteTrunkBranch())
+	if err == nil && onTrunk {
+		return true, nil
 	}
-	os.Setenv("GITHUB_TOKEN", token)
-	defer os.Setenv("GITHUB_TOKEN", "")
-
-	// changelog-gen -repo $REPO_NAME -branch main -owner hashicorp -changelog ${MM_REPO}/.ci/changelog.tmpl -releasenote ${MM_REPO}/.ci/release-note.tmpl -no-note-label "changelog: no-release-note" $COMMIT_SHA_OF_LAST_RELEASE $COMMIT_SHA_OF_LAST_COMMIT_IN_CURRENT_RELEASE
-	cl := changelog.ChangeLogRun{
-		Input:                    input,
-		Config:                   c,
-		LastReleaseCommit:        lastReleaseCommit,
-		LastCommitCurrentRelease: lastCommitCurrentRelease,
 
-		Dir: dir,
+	// The commit can be newer than the last fetch, e.g. a SHA supplied by a flag that wasn't picked from the list
+	cmd, err := gi.FetchTrunkBranch()
+	if err != nil {
+		return false, &failure.GitError{Summary: fmt.Sprintf("error when fetching %s", gi.RemoteTrunkBranch()), Command: cmd}
 	}
-	cl.GenerateChangelog()
-	output := cl.String()
-
-	fmt.Print("\n---\n")
-	fmt.Printf("\n\033[32m" + output)
-	fmt.Print("\n---\n")
-
-	log.Printf("Copy the CHANGELOG above into : https://github.com/%s/%s/edit/release-%s/CHANGELOG.md", c.RemoteOwner, input.GetProviderRepoName(), input.ReleaseVersion)
-
+	// A commit that isn't in the clone at all, e.g. one from the other provider's repository, makes git merge-base fail
+	// rather than answer no, which would be reported as a git failure instead of a wrong input
+	if _, _, err := gi.GetCommit(sha); err != nil {
+		return false, nil
+	}
+	onTrunk, cmd, err = gi.IsAncestor(sha, gi.RemoteTrunkBranch())
+	if err != nil {
+		return false, &failure.GitError{Summary: fmt.Sprintf("error when checking if %s is on %s", sha, gi.RemoteTrunkBranch()), Command: cmd}
+	}
+	return onTrunk, nil
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git/gittest"
+	input_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+)
+
+func Test_waitAfterInterrupt(t *testing.T) {
+	cases := map[string]struct {
+		// stepErr is the error the interrupted step stopped with
+		stepErr      error
+		expectedCode failure.ExitCode
+	}{
+		"release finished": {
+			stepErr:      nil,
+			expectedCode: failure.EXIT_OK,
+		},
+		"fetching interrupted": {
+			stepErr:      errors.New("signal: interrupt"),
+			expectedCode: failure.EXIT_CANCELLED,
+		},
+		"invalid input while interrupted": {
+			stepErr:      &failure.InputError{Err: errors.New("invalid version")},
+			expectedCode: failure.EXIT_CANCELLED,
+		},
+		"push interrupted": {
+			stepErr:      &failure.PushMismatchError{Branch: "release-1.1.0", Remote: "upstream"},
+			expectedCode: failure.EXIT_GIT_PUSH,
+		},
+		"changelog generation interrupted": {
+			stepErr:      &failure.ChangelogError{Err: errors.New("signal: interrupt")},
+			expectedCode: failure.EXIT_CHANGELOG,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			handler := input_pkg.NewHandler(&input_pkg.Input{}, nil)
+			r := release{handler: &handler}
+			done := make(chan error, 1)
+			done <- tc.stepErr
+
+			err := waitAfterInterrupt(&r, done)
+			if code := failure.Code(err); code != tc.expectedCode {
+				t.Fatalf("wanted exit code %d, got %d for %v", tc.expectedCode, code, err)
+			}
+			if tc.stepErr != nil && !errors.Is(err, tc.stepErr) {
+				t.Fatalf("expected the step's error to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_trunkCommitFinder_IsOnTrunk(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	clone := remote.NewClone(t)
+	gittest.Run(t, clone, "fetch", "-q", "upstream", "main", "--tags")
+	// A commit pushed to the trunk branch after the clone last fetched
+	newer := gittest.NewRepo(t, "", map[string]string{"upstream": remote.Path})
+	gittest.Run(t, newer, "fetch", "-q", "upstream", "main")
+	gittest.Run(t, newer, "checkout", "-q", "-b", "main", "upstream/main")
+	gittest.Run(t, newer, "commit", "-q", "--allow-empty", "-m", "newer")
+	gittest.Run(t, newer, "push", "-q", "upstream", "main")
+	newerCommit := gittest.Run(t, newer, "rev-parse", "HEAD")
+
+	target := &config.Target{Name: "ga", Repo: config.GA_REPO_NAME, Path: clone, Remote: "upstream", TrunkBranch: "main"}
+	finder := trunkCommitFinder{ctx: context.Background(), timeout: time.Minute}
+
+	cases := map[string]struct {
+		sha      string
+		expected bool
+	}{
+		"commit on the trunk branch": {
+			sha:      remote.Branched,
+			expected: true,
+		},
+		"commit newer than the last fetch": {
+			sha:      newerCommit,
+			expected: true,
+		},
+		"commit on a release branch": {
+			sha:      remote.Release,
+			expected: false,
+		},
+		"commit that isn't in the clone, e.g. from the other provider": {
+			sha:      "4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d",
+			expected: false,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			got, err := finder.IsOnTrunk(target, tc.sha)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.expected {
+				t.Fatalf("wanted %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/changelog"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/config"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/contributors"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/doctor"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/git"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/github"
+	input_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/input"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/retry"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
+	token_pkg "github.com/SarahFrench/terraform-provider-google-release-cli/internal/token"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/tui"
+)
+
+// release holds the state of making a release as it moves through each step
+type release struct {
+	// ctx stops running git commands when it's cancelled, e.g. when the user presses Ctrl-C
+	ctx         context.Context
+	config      *config.Config
+	resolver    *input_pkg.Resolver
+	handler     *input_pkg.Handler
+	input       *input_pkg.Input
+	githubToken string
+	edit        bool
+	// yes skips confirming the release details before the release branch is created and pushed
+	yes bool
+
+	// editChangelog lets the user edit the changelog, e.g. in their $EDITOR
+	editChangelog func(changelog string) (string, error)
+	// publish shows the user the finished changelog and where to copy it to
+	publish func(changelog, url string)
+
+	templates                *changelog.Templates
+	token                    string
+	gi                       git.GitInteract
+	lastReleaseCommit        string
+	lastCommitCurrentRelease string
+	branchName               string
+	changelog                string
+
+	// checkedOut is the branch or commit that was checked out in the clone before the release, so that it can be checked
+	// out again if the release stops part way, see restoreClone
+	checkedOut string
+	// createdBranch is true once the local release branch has been created
+	createdBranch bool
+
+	// stepResults records how each step went, see timeSteps
+	stepResults []stepResult
+}
+
+// steps returns the steps of making a release, in the order they're run
+func (r *release) steps() []tui.Step {
+	return r.offerFixes([]tui.Step{
+		{Name: "Collect inputs", Run: r.collectInputs},
+		{Name: "Pre-flight checks", Run: r.preflightChecks},
+		{Name: "Confirm release details", Run: r.confirm},
+		{Name: "Cut release branch", Run: r.cutReleaseBranch},
+		{Name: "Push release branch", Run: r.pushReleaseBranch},
+		{Name: "Generate changelog", Run: r.generateChangelog},
+		{Name: "Publish", Run: r.showChangelog},
+	})
+}
+
+// offerFixes wraps each step so that if a git command fails in a way that can be fixed without losing any work, e.g.
+// by stashing local changes, the user is offered the fix and the step is run again
+func (r *release) offerFixes(steps []tui.Step) []tui.Step {
+	for i, s := range steps {
+		run := s.Run
+		steps[i].Run = func() error {
+			err := run()
+			var gitErr *failure.GitError
+			if !errors.As(err, &gitErr) {
+				return err
+			}
+			rem := gitErr.Command.Remediation()
+			if rem == nil || rem.Fix == nil || r.resolver.NonInteractive {
+				return err
+			}
+
+			r.handler.Notify(err.Error())
+			ok, askErr := r.handler.Confirm(fmt.Sprintf("Do you want to %s by running `%s`, and try again?", rem.Fix.Description, rem.Fix.Command()))
+			if askErr != nil || !ok {
+				return err
+			}
+			if cmd, fixErr := gitErr.Command.RunFix(*rem.Fix); fixErr != nil {
+				return &failure.GitError{Summary: fmt.Sprintf("error when trying to %s", rem.Fix.Description), Command: cmd}
+			}
+			log.Printf("Ran `%s`, trying again", rem.Fix.Command())
+			return run()
+		}
+	}
+	return steps
+}
+
+func (r *release) collectInputs() error {
+	if err := r.resolver.Resolve(); err != nil {
+		return &failure.InputError{Err: fmt.Errorf("error collecting user inputs: %w", err)}
+	}
+	r.handler.Notify(fmt.Sprintf("\nMaking a release for %s using these inputs:\n\n%s", r.input.GetProviderRepoName(), r.resolver.ProvenanceTable()))
+	return nil
+}
+
+func (r *release) preflightChecks() error {
+	// Make sure the changelog templates are usable before any changes are made
+	templates, err := changelog.ResolveTargetTemplates(r.input.Target, r.config.MagicModulesPath)
+	if err != nil {
+		return &failure.ConfigError{Err: err}
+	}
+	r.templates = templates
+	for _, w := range templates.Warnings {
+		log.Printf("Warning: %s", w)
+	}
+
+	token, tokenSource, err := token_pkg.Resolve(r.githubToken, r.config)
+	if err != nil {
+		return &failure.ConfigError{Err: err}
+	}
+	r.token = token
+	log.Printf("Using GitHub token from %s", tokenSource)
+
+	r.gi = git.GitInteract{
+		Dir:             r.input.Target.Path,
+		PreviousRelease: r.input.Target.TagName(r.input.PreviousReleaseVersion),
+		Remote:          r.input.Target.Remote,
+		TrunkBranch:     r.input.Target.TrunkBranch,
+		Context:         r.ctx,
+		Timeout:         r.config.GetGitTimeout(),
+		Progress:        log.Writer(),
+		Retry:           retry.DEFAULT_POLICY,
+	}
+
+	checkedOut, cmd, err := r.gi.GetCheckedOut()
+	if err != nil {
+		return &failure.GitError{Summary: "error when finding the branch that's checked out", Command: cmd}
+	}
+	r.checkedOut = checkedOut
+
+	// git fetch $REMOTE main --tags
+	// This replaces `git pull` in the documented process, so nothing is merged into the branch that's checked out. It
+	// runs before the previous release's tag is looked up, so that a tag that was pushed after the last fetch is found.
+	cmd, err = r.gi.FetchTagsTrunkBranch()
+	if err != nil {
+		return &failure.GitError{Summary: fmt.Sprintf("error when fetching %s and tags", r.gi.RemoteTrunkBranch()), Command: cmd}
+	}
+
+	// The merge-base is resolved against the remote-tracking trunk branch that was just fetched, e.g. upstream/main, so
+	// the local trunk branch doesn't need to be checked out or up to date, and the summary shows the merge-base that's
+	// used for the changelog
+	lastReleaseCommit, cmd, err := r.gi.GetLastReleaseCommit()
+	if err != nil {
+		return &failure.GitError{Summary: "error when getting last release's commit", Command: cmd}
+	}
+	r.lastReleaseCommit = lastReleaseCommit
+	r.branchName = r.input.Target.BranchName(r.input.ReleaseVersion)
+	return nil
+}
+
+// confirm shows a summary of the release and asks the user to confirm it before the release branch is created and
+// pushed, so that a wrong clone, remote or commit can be caught. The trunk branch and tags have already been fetched,
+// which doesn't change any local branches.
+func (r *release) confirm() error {
+	summary, err := r.summary()
+	if err != nil {
+		return err
+	}
+	r.handler.Notify(summary)
+
+	if r.yes {
+		log.Print("Not asking for confirmation because -yes was set")
+		return nil
+	}
+	ok, err := r.handler.Confirm(fmt.Sprintf("Do you want to continue and run these commands against the %s remote?", r.gi.Remote))
+	if err != nil {
+		return &failure.InputError{Err: err}
+	}
+	if !ok {
+		return fmt.Errorf("%w, release stopped before the release branch was created or pushed", failure.ErrCancelled)
+	}
+	return nil
+}
+
+// summary describes the release and the commands that will be run against the remote
+func (r *release) summary() (string, error) {
+	t := r.input.Target
+
+	remotes, cmd, err := r.gi.GetRemotes()
+	if err != nil {
+		return "", &failure.GitError{Summary: "error when listing remotes", Command: cmd}
+	}
+	remoteURL, ok := remotes[r.gi.Remote]
+	if !ok {
+		remoteURL = "remote doesn't exist"
+	}
+	// Remote URLs can contain tokens
+	remoteURL = secrets.Redact(remoteURL)
+
+	subject := "not found in local clone"
+	if commits, _, err := r.gi.GetRecentCommits(r.input.CommitSha, 1); err == nil && len(commits) == 1 {
+		subject = commits[0].Subject()
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\nRelease details:\n\n")
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Provider repository\t%s/%s\n", t.Owner, t.Repo)
+	fmt.Fprintf(w, "Local path\t%s\n", t.Path)
+	fmt.Fprintf(w, "Remote\t%s (%s)\n", r.gi.Remote, remoteURL)
+	fmt.Fprintf(w, "Release branch\t%s\n", r.branchName)
+	fmt.Fprintf(w, "Commit\t%s (%s)\n", r.input.CommitSha, subject)
+	fmt.Fprintf(w, "Previous release\t%s (tag %s, merge-base %s)\n", r.input.PreviousReleaseVersion, r.gi.PreviousRelease, r.lastReleaseCommit)
+	w.Flush()
+
+	b.WriteString("\nThese commands will be run against the remote:\n\n")
+	for _, c := range r.remoteCommands() {
+		fmt.Fprintf(&b, "\t%s\n", c)
+	}
+
+	// Problems that doctor would find, such as a clone of the wrong repository, are easy to miss in the summary. They
+	// can include the remote's URL, which can contain a token.
+	for _, result := range doctor.CheckProviderClone(t) {
+		if result.Status != doctor.PASS {
+			fmt.Fprintf(&b, "\nWarning: %s\n", secrets.Redact(result.Details))
+		}
+	}
+	return b.String(), nil
+}
+
+// remoteCommands returns the commands that are run against the remote once the release is confirmed, in the order
+// they're run. New steps that affect the remote must be added here so that they're included in the summary.
+func (r *release) remoteCommands() []string {
+	return []string{
+		fmt.Sprintf("git push -u %s %s", r.gi.Remote, r.branchName),
+		fmt.Sprintf("git ls-remote --heads %s refs/heads/%s", r.gi.Remote, r.branchName),
+	}
+}
+
+func (r *release) cutReleaseBranch() error {
+	log.Print("Starting to create and push new release branch")
+
+	// git checkout $COMMIT_SHA
+	cmd, err := r.gi.Checkout(r.input.CommitSha)
+	if err != nil {
+		return &failure.GitError{Summary: "error when checking out provided commit SHA", Command: cmd}
+	}
+
+	// git checkout -b release-$RELEASE_VERSION
+	cmd, err = r.gi.CreateReleaseBranch(r.branchName)
+	if err != nil {
+		return &failure.GitError{Summary: "error when creating a new release branch", Command: cmd}
+	}
+	r.createdBranch = true
+	return nil
+}
+
+func (r *release) pushReleaseBranch() error {
+	// git push -u $REMOTE release-$RELEASE_VERSION
+	cmd, err := r.gi.PushReleaseBranch(r.branchName)
+	if err != nil {
+		return &failure.GitError{Summary: "error when pushing the new release branch", Command: cmd, Push: true}
+	}
+
+	// The release process runs `git rev-list -n 1 HEAD`, which only shows the local checkout. Asking the remote
+	// catches a push that went somewhere else, e.g. a fork set as the remote's push URL.
+	releaseCommit, cmd, err := r.gi.GetCommit(r.input.CommitSha)
+	if err != nil {
+		return &failure.GitError{Summary: "error when getting the full SHA of the release commit", Command: cmd}
+	}
+	remoteCommit, cmd, err := r.gi.GetRemoteBranchCommit(r.branchName)
+	if err != nil {
+		return &failure.GitError{Summary: fmt.Sprintf("error when checking %s on the %s remote", r.branchName, r.gi.Remote), Command: cmd, Push: true}
+	}
+	if remoteCommit != releaseCommit {
+		return &failure.PushMismatchError{Branch: r.branchName, Remote: r.gi.Remote, Expected: releaseCommit, Actual: remoteCommit}
+	}
+	r.lastCommitCurrentRelease = releaseCommit
+
+	log.Printf("Release branch %s was created and pushed, and points at %s on the %s remote", r.branchName, releaseCommit, r.gi.Remote)
+	return nil
+}
+
+func (r *release) generateChangelog() error {
+	log.Println("Creating CHANGELOG entry")
+
+	// changelog-gen -repo $REPO_NAME -branch main -owner hashicorp -changelog ${MM_REPO}/.ci/changelog.tmpl -releasenote ${MM_REPO}/.ci/release-note.tmpl -no-note-label "changelog: no-release-note" $COMMIT_SHA_OF_LAST_RELEASE $COMMIT_SHA_OF_LAST_COMMIT_IN_CURRENT_RELEASE
+	cl := changelog.ChangeLogRun{
+		Input:                    *r.input,
+		LastReleaseCommit:        r.lastReleaseCommit,
+		LastCommitCurrentRelease: r.lastCommitCurrentRelease,
+		Templates:                r.templates,
+		GitHubToken:              r.token,
+
+		Dir: r.input.Target.Path,
+	}
+	if err := generateChangelog(r.ctx, &cl); err != nil {
+		return err
+	}
+	output := cl.String()
+
+	// Commits in the release are used to find upstream PRs and contributors
+	gh := github.New(r.token)
+	commits, cmd, err := r.gi.GetCommitsInRange(r.lastReleaseCommit, r.lastCommitCurrentRelease)
+	if err != nil {
+		log.Print(cmd.ErrorDescription("Warning: unable to list commits in the release"))
+		commits = nil
+	}
+
+	// Link to the magic-modules PRs that changes originated from, if configured
+	if r.config.ChangelogLinks != config.CHANGELOG_LINKS_DOWNSTREAM && commits != nil {
+		upstream, err := changelog.FindUpstreamPullRequests(r.ctx, gh, r.input.Target.Owner, r.input.GetProviderRepoName(), commits)
+		if err != nil {
+			log.Printf("Warning: unable to link changelog entries to magic-modules PRs: %s", err)
+		} else {
+			output = changelog.RewriteLinks(output, upstream, r.config.ChangelogLinks)
+		}
+	}
+
+	// Let the user tweak the changelog, making sure it's still in the expected format afterwards
+	if r.edit {
+		for {
+			output, err = r.editChangelog(output)
+			if err != nil {
+				return &failure.ChangelogError{Err: err}
+			}
+			err = changelog.Validate(output)
+			if err == nil {
+				break
+			}
+			r.handler.Notify(err.Error())
+			again, err := r.handler.Confirm("Do you want to edit the changelog again?")
+			if err != nil || !again {
+				r.handler.Notify("\n---\n\n" + output + "\n---\n")
+				return &failure.ChangelogError{Err: errors.New("the edited changelog above is not valid, exiting")}
+			}
+		}
+	}
+
+	// Credit the authors of the PRs included in the release
+	if commits != nil {
+		finder := contributors.Finder{
+			GitHub:      gh,
+			Owner:       r.input.Target.Owner,
+			Repo:        r.input.GetProviderRepoName(),
+			Maintainers: r.config.Maintainers,
+		}
+		names, err := finder.Find(r.ctx, commits)
+		if err != nil {
+			log.Printf("Warning: unable to credit contributors: %s", err)
+		} else if section := contributors.Section(names); section != "" {
+			output = output + "\n" + section
+		}
+	}
+
+	r.changelog = output
+	return nil
+}
+
+func (r *release) showChangelog() error {
+	r.publish(r.changelog, r.changelogURL())
+	return nil
+}
+
+// changelogURL is where the changelog is copied to on GitHub
+func (r *release) changelogURL() string {
+	return fmt.Sprintf("https://github.com/%s/%s/edit/%s/CHANGELOG.md", r.input.Target.Owner, r.input.GetProviderRepoName(), r.branchName)
+}
+
+// restoreClone checks out the branch that was checked out before the release, after the release stops part way, e.g.
+// because the user pressed Ctrl-C. A local release branch that wasn't confirmed on the remote is left for the user to
+// delete, because the push may have reached the remote before it was stopped.
+func (r *release) restoreClone() {
+	if r.checkedOut == "" {
+		return
+	}
+	// The release's context may have been cancelled, which would stop these commands straight away
+	gi := r.gi
+	gi.Context = nil
+
+	if current, _, err := gi.GetCheckedOut(); err != nil || current != r.checkedOut {
+		if cmd, err := gi.Checkout(r.checkedOut); err != nil {
+			log.Print(cmd.ErrorDescription(fmt.Sprintf("Warning: unable to check out %s again, run `git checkout %s` in %s", r.checkedOut, r.checkedOut, gi.Dir)))
+		} else {
+			log.Printf("Checked out %s again, which was checked out before the release", r.checkedOut)
+		}
+	}
+	if r.createdBranch && r.lastCommitCurrentRelease == "" {
+		log.Printf("The local release branch %s wasn't confirmed on the %s remote. Check whether it was pushed, and delete it with `git branch -D %s` in %s before making the release again", r.branchName, gi.Remote, r.branchName, gi.Dir)
+	}
+}
+
+func (r *release) cleanup() {
+	if r.templates != nil {
+		r.templates.Cleanup()
+	}
+}
+
+// printChangelog prints the changelog and where to copy it to on the command line
+func printChangelog(changelog, url string) {
+	fmt.Print("\n---\n")
+	fmt.Printf("\n\033[32m" + changelog)
+	fmt.Print("\n---\n")
+
+	log.Printf("Copy the CHANGELOG above into : %s", url)
+}
+
+// generateChangelog runs changelog-gen. It makes many requests to the GitHub API, so it's retried if any of them fail
+// because of the network. Waiting to retry stops early if ctx is cancelled.
+func generateChangelog(ctx context.Context, cl *changelog.ChangeLogRun) error {
+	err := retry.DEFAULT_POLICY.Do(ctx,
+		cl.GenerateChangelog,
+		func(error) bool {
+			return retry.IsTransientOutput(cl.StdErr.String())
+		},
+		func(err error, wait time.Duration, attempt int) {
+			log.Print(retry.DEFAULT_POLICY.Message("Generating the changelog", wait, attempt))
+		},
+	)
+	if err != nil {
+		return &failure.ChangelogError{Err: fmt.Errorf("error when running %s: %w\n%s", changelogExecutable, err, cl.StdErr.String())}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/failure"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/secrets"
+	"github.com/SarahFrench/terraform-provider-google-release-cli/internal/tui"
+)
+
+type stepStatus string
+
+const (
+	STEP_SUCCEEDED stepStatus = "succeeded"
+	STEP_FAILED    stepStatus = "failed"
+	STEP_SKIPPED   stepStatus = "skipped"
+)
+
+// stepResult describes how a step of the release went
+type stepResult struct {
+	Name            string     `json:"name"`
+	Status          stepStatus `json:"status"`
+	DurationSeconds float64    `json:"durationSeconds"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// releaseResult is the JSON document that describes the outcome of a release, e.g. for a CI pipeline to use
+type releaseResult struct {
+	Succeeded              bool             `json:"succeeded"`
+	ExitCode               failure.ExitCode `json:"exitCode"`
+	Error                  string           `json:"error,omitempty"`
+	Target                 string           `json:"target,omitempty"`
+	Repository             string           `json:"repository,omitempty"`
+	ReleaseVersion         string           `json:"releaseVersion,omitempty"`
+	PreviousReleaseVersion string           `json:"previousReleaseVersion,omitempty"`
+	Branch                 string           `json:"branch,omitempty"`
+	ReleaseCommit          string           `json:"releaseCommit,omitempty"`
+	LastReleaseCommit      string           `json:"lastReleaseCommit,omitempty"`
+	Changelog              string           `json:"changelog,omitempty"`
+	URLs                   *releaseURLs     `json:"urls,omitempty"`
+	Steps                  []stepResult     `json:"steps"`
+}
+
+type releaseURLs struct {
+	Branch          string `json:"branch"`
+	EditChangelog   string `json:"editChangelog"`
+	CompareReleases string `json:"compareReleases"`
+}
+
+// timeSteps wraps each step so that its status and duration are recorded in the release's step results
+func (r *release) timeSteps(steps []tui.Step) []tui.Step {
+	r.stepResults = make([]stepResult, len(steps))
+	timed := make([]tui.Step, len(steps))
+	for i, s := range steps {
+		r.stepResults[i] = stepResult{Name: s.Name, Status: STEP_SKIPPED}
+		run := s.Run
+		timed[i] = tui.Step{
+			Name: s.Name,
+			Run: func() error {
+				start := time.Now()
+				err := run()
+				r.stepResults[i].DurationSeconds = time.Since(start).Seconds()
+				r.stepResults[i].Status = STEP_SUCCEEDED
+				if err != nil {
+					r.stepResults[i].Status = STEP_FAILED
+					r.stepResults[i].Error = err.Error()
+				}
+				return err
+			},
+		}
+	}
+	return timed
+}
+
+// result describes the outcome of the release, including the values of any steps that were completed. Errors can include
+// the output of git and changelog-gen, so secrets are redacted from them.
+func (r *release) result(err error) releaseResult {
+	res := releaseResult{
+		Succeeded:         err == nil,
+		ExitCode:          failure.Code(err),
+		Steps:             make([]stepResult, len(r.stepResults)),
+		Branch:            r.branchName,
+		ReleaseCommit:     r.lastCommitCurrentRelease,
+		LastReleaseCommit: r.lastReleaseCommit,
+		Changelog:         secrets.Redact(r.changelog),
+	}
+	for i, s := range r.stepResults {
+		s.Error = secrets.Redact(s.Error)
+		res.Steps[i] = s
+	}
+	if err != nil {
+		res.Error = secrets.Redact(err.Error())
+	}
+	if t := r.input.Target; t != nil {
+		res.Target = t.Name
+		res.Repository = fmt.Sprintf("%s/%s", t.Owner, t.Repo)
+		res.ReleaseVersion = r.input.ReleaseVersion
+		res.PreviousReleaseVersion = r.input.PreviousReleaseVersion
+		if r.branchName != "" {
+			res.URLs = &releaseURLs{
+				Branch:          fmt.Sprintf("https://github.com/%s/%s/tree/%s", t.Owner, t.Repo, r.branchName),
+				EditChangelog:   r.changelogURL(),
+				CompareReleases: fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", t.Owner, t.Repo, t.TagName(r.input.PreviousReleaseVersion), r.branchName),
+			}
+		}
+	}
+	return res
+}
+
+// writeResult writes the result of the release to the file at path, or to stdout if path is -. Nothing is written if
+// path is empty, and a failure to write is only a warning, so that it doesn't hide why the release failed.
+func (r *release) writeResult(path string, err error) {
+	if path == "" {
+		return
+	}
+	if err := writeResult(path, r.result(err)); err != nil {
+		log.Printf("Warning: %s", err)
+	}
+}
+
+// writeResult writes the result as JSON to the file at path, or to stdout if path is -
+func writeResult(path string, res releaseResult) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing result to %s: %w", path, err)
+	}
+	return nil
+}